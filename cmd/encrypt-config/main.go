@@ -0,0 +1,63 @@
+// Command encrypt-config produces enc: values for config files.
+//
+// It reads the key the service decrypts with, from CONFIG_KEY or the file
+// named by CONFIG_KEY_FILE, and the plaintext from stdin, so secrets stay out
+// of shell history:
+//
+//	encrypt-config -genkey > config.key
+//	CONFIG_KEY_FILE=config.key encrypt-config < db-password.txt
+//
+// One trailing newline is stripped from the plaintext. The printed value
+// goes in the config file as is, e.g. database_url: enc:aes256gcm:...
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"marketflash/internal/config"
+)
+
+func main() {
+	genkey := flag.Bool("genkey", false, "print a new random key, base64 encoded, and exit")
+	flag.Parse()
+
+	if err := run(*genkey); err != nil {
+		fmt.Fprintln(os.Stderr, "encrypt-config:", err)
+		os.Exit(1)
+	}
+}
+
+func run(genkey bool) error {
+	if genkey {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return err
+		}
+		fmt.Println(base64.StdEncoding.EncodeToString(key))
+		return nil
+	}
+
+	key, err := config.LoadConfigKey()
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return fmt.Errorf("reading plaintext: %w", err)
+	}
+
+	value, err := config.EncryptValue(key, strings.TrimSuffix(strings.TrimSuffix(string(plaintext), "\n"), "\r"))
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(value)
+	return nil
+}
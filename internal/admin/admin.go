@@ -0,0 +1,167 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+
+	"marketflash/internal/auth"
+	"marketflash/internal/provider"
+	"marketflash/internal/validate"
+)
+
+// Options wires the admin surface to the running app. Routes whose
+// dependency is nil respond 404.
+type Options struct {
+	// Config is the effective config, already redacted; see config.Redacted.
+	Config any
+	// Subscriptions returns the symbols currently subscribed upstream.
+	Subscriptions func() []string
+	// LogLevel is the level of the app's slog handlers.
+	LogLevel *slog.LevelVar
+	Provider provider.Provider
+	// Shadow is the shadow comparison, when one is configured.
+	Shadow *provider.Shadow
+	// Router is the per-symbol provider routing, when routes are configured.
+	Router *provider.Router
+}
+
+type logLevel struct {
+	Level string `json:"level"`
+}
+
+type routePriority struct {
+	Providers []string `json:"providers"`
+}
+
+// Handler serves operator controls:
+//
+//	GET  /admin/config              the effective redacted config, as YAML
+//	GET  /admin/subscriptions       symbols subscribed upstream
+//	GET  /admin/log-level           the current log level
+//	PUT  /admin/log-level           change the log level, e.g. {"level":"debug"}
+//	POST /admin/provider/reconnect  drop and re-establish provider streams
+//	GET  /admin/shadow              the shadow provider comparison report
+//	GET  /admin/routing             provider routes, in match order
+//	PUT  /admin/routing/{index}     change a route's failover order,
+//	                                e.g. {"providers":["b","a"]}
+//
+// Every route requires the admin scope. It must be mounted behind
+// auth.Manager.Middleware.
+func Handler(opts Options) http.Handler {
+	mux := http.NewServeMux()
+
+	if opts.Config != nil {
+		mux.HandleFunc("GET /admin/config", func(w http.ResponseWriter, r *http.Request) {
+			data, err := yaml.Marshal(opts.Config)
+			if err != nil {
+				validate.WriteStatus(w, http.StatusInternalServerError, err)
+				return
+			}
+			w.Header().Set("Content-Type", "application/yaml")
+			_, _ = w.Write(data)
+		})
+	}
+
+	if opts.Subscriptions != nil {
+		mux.HandleFunc("GET /admin/subscriptions", func(w http.ResponseWriter, r *http.Request) {
+			symbols := opts.Subscriptions()
+			if symbols == nil {
+				symbols = []string{}
+			}
+			writeJSON(w, http.StatusOK, symbols)
+		})
+	}
+
+	if opts.LogLevel != nil {
+		mux.HandleFunc("GET /admin/log-level", func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusOK, logLevel{Level: opts.LogLevel.Level().String()})
+		})
+
+		mux.HandleFunc("PUT /admin/log-level", func(w http.ResponseWriter, r *http.Request) {
+			var req logLevel
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				validate.WriteError(w, fmt.Errorf("%w: %w", validate.ErrInvalidBody, err))
+				return
+			}
+
+			var level slog.Level
+			if err := level.UnmarshalText([]byte(req.Level)); err != nil {
+				validate.WriteStatus(w, http.StatusBadRequest, err)
+				return
+			}
+
+			opts.LogLevel.Set(level)
+			writeJSON(w, http.StatusOK, logLevel{Level: level.String()})
+		})
+	}
+
+	if opts.Provider != nil {
+		mux.HandleFunc("POST /admin/provider/reconnect", func(w http.ResponseWriter, r *http.Request) {
+			rc, ok := opts.Provider.(provider.Reconnecter)
+			if !ok {
+				validate.WriteProblem(w, validate.Problem{Status: http.StatusNotImplemented, Detail: provider.ErrCannotReconnect.Error()})
+				return
+			}
+
+			err := rc.Reconnect(r.Context())
+			switch {
+			case errors.Is(err, provider.ErrCannotReconnect):
+				validate.WriteProblem(w, validate.Problem{Status: http.StatusNotImplemented, Detail: provider.ErrCannotReconnect.Error()})
+			case err != nil:
+				validate.WriteStatus(w, http.StatusBadGateway, err)
+			default:
+				w.WriteHeader(http.StatusNoContent)
+			}
+		})
+	}
+
+	if opts.Shadow != nil {
+		mux.HandleFunc("GET /admin/shadow", func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusOK, opts.Shadow.Report())
+		})
+	}
+
+	if opts.Router != nil {
+		mux.HandleFunc("GET /admin/routing", func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusOK, opts.Router.Routes())
+		})
+
+		mux.HandleFunc("PUT /admin/routing/{index}", func(w http.ResponseWriter, r *http.Request) {
+			index, err := strconv.Atoi(r.PathValue("index"))
+			if err != nil {
+				validate.WriteProblem(w, validate.Problem{Status: http.StatusBadRequest, Detail: "invalid route index"})
+				return
+			}
+
+			var req routePriority
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				validate.WriteError(w, fmt.Errorf("%w: %w", validate.ErrInvalidBody, err))
+				return
+			}
+
+			err = opts.Router.SetPriority(index, req.Providers)
+			switch {
+			case errors.Is(err, provider.ErrUnknownRoute):
+				validate.WriteStatus(w, http.StatusNotFound, err)
+			case err != nil:
+				validate.WriteStatus(w, http.StatusBadRequest, err)
+			default:
+				writeJSON(w, http.StatusOK, opts.Router.Routes()[index])
+			}
+		})
+	}
+
+	return auth.RequireScope(auth.ScopeAdmin)(mux)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
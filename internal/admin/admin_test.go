@@ -0,0 +1,115 @@
+package admin
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"marketflash/internal/auth"
+	"marketflash/internal/config"
+	"marketflash/internal/provider"
+)
+
+// staticProvider is a provider without reconnect support.
+type staticProvider struct{}
+
+func (staticProvider) Subscribe(context.Context, []string) error   { return nil }
+func (staticProvider) Unsubscribe(context.Context, []string) error { return nil }
+func (staticProvider) Ticks() <-chan provider.Tick                 { return nil }
+func (staticProvider) Run(context.Context) error                   { return nil }
+
+func TestHandler(t *testing.T) {
+	level := &slog.LevelVar{}
+
+	handler := Handler(Options{
+		Config:        map[string]string{"api_key": "REDACTED", "environment": "staging"},
+		Subscriptions: func() []string { return []string{"AAPL", "MSFT"} },
+		LogLevel:      level,
+		Provider:      provider.NewSimulator(config.SimulatorConfig{}),
+		Shadow: provider.NewShadow(config.ShadowConfig{},
+			provider.Source{Name: "primary", Provider: staticProvider{}},
+			provider.Source{Name: "premium", Provider: staticProvider{}}),
+	})
+
+	do := func(h http.Handler, method, path string, scopes []auth.Scope, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req = req.WithContext(auth.WithPrincipal(req.Context(), auth.Principal{KeyID: "k1", Scopes: scopes}))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	admin := []auth.Scope{auth.ScopeAdmin}
+
+	if rec := do(handler, http.MethodGet, "/admin/config", []auth.Scope{auth.ScopeReadQuotes}, ""); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for non-admin key, got %d", rec.Code)
+	}
+
+	rec := do(handler, http.MethodGet, "/admin/config", admin, "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "environment: staging") {
+		t.Errorf("expected yaml config, got %d: %s", rec.Code, rec.Body)
+	}
+
+	rec = do(handler, http.MethodGet, "/admin/subscriptions", admin, "")
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != `["AAPL","MSFT"]` {
+		t.Errorf("expected subscriptions, got %d: %s", rec.Code, rec.Body)
+	}
+
+	rec = do(handler, http.MethodPut, "/admin/log-level", admin, `{"level":"debug"}`)
+	if rec.Code != http.StatusOK || level.Level() != slog.LevelDebug {
+		t.Errorf("expected level debug, got %d and %s", rec.Code, level.Level())
+	}
+
+	if rec := do(handler, http.MethodPut, "/admin/log-level", admin, `{"level":"loud"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for unknown level, got %d", rec.Code)
+	}
+
+	rec = do(handler, http.MethodGet, "/admin/log-level", admin, "")
+	if !strings.Contains(rec.Body.String(), `"DEBUG"`) {
+		t.Errorf("expected current level, got %s", rec.Body)
+	}
+
+	if rec := do(handler, http.MethodPost, "/admin/provider/reconnect", admin, ""); rec.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d", rec.Code)
+	}
+
+	rec = do(handler, http.MethodGet, "/admin/shadow", admin, "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"provider":"premium"`) {
+		t.Errorf("expected shadow report, got %d: %s", rec.Code, rec.Body)
+	}
+
+	router := provider.NewRouter([]provider.Route{
+		{Failover: provider.NewFailover(config.FailoverConfig{}, []provider.Source{
+			{Name: "primary", Provider: staticProvider{}},
+			{Name: "backup", Provider: staticProvider{}},
+		})},
+	})
+	routed := Handler(Options{Router: router})
+
+	rec = do(routed, http.MethodGet, "/admin/routing", admin, "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"providers":["primary","backup"]`) {
+		t.Errorf("expected routes, got %d: %s", rec.Code, rec.Body)
+	}
+
+	rec = do(routed, http.MethodPut, "/admin/routing/0", admin, `{"providers":["backup","primary"]}`)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"providers":["backup","primary"]`) {
+		t.Errorf("expected reordered route, got %d: %s", rec.Code, rec.Body)
+	}
+
+	if rec := do(routed, http.MethodPut, "/admin/routing/0", admin, `{"providers":["backup"]}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for incomplete priority, got %d", rec.Code)
+	}
+	if rec := do(routed, http.MethodPut, "/admin/routing/3", admin, `{"providers":[]}`); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown route, got %d", rec.Code)
+	}
+
+	static := Handler(Options{Provider: staticProvider{}})
+	if rec := do(static, http.MethodPost, "/admin/provider/reconnect", admin, ""); rec.Code != http.StatusNotImplemented {
+		t.Errorf("expected 501 for provider without reconnect, got %d", rec.Code)
+	}
+	if rec := do(static, http.MethodGet, "/admin/config", admin, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 without config, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,256 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"marketflash/internal/validate"
+)
+
+var (
+	ErrDuplicateComponent = errors.New("duplicate component name")
+	ErrUnknownDependency  = errors.New("unknown dependency")
+	ErrDependencyCycle    = errors.New("dependency cycle")
+)
+
+// Component is a long-lived subsystem such as the API server, ingestion, the
+// aggregator, or the alerter.
+type Component interface {
+	// Start brings the component up and returns once it is ready for the
+	// components that depend on it. ctx stays valid until every component
+	// has stopped, so background work may be bound to it.
+	Start(ctx context.Context) error
+	// Stop drains in-flight work (flushing buffers, closing client
+	// connections, finishing requests) and releases resources. ctx expires
+	// at the shutdown deadline.
+	Stop(ctx context.Context) error
+}
+
+// Drainer is implemented by components that hold long-lived client
+// connections. Drain is called when the drain period starts, while the
+// component still serves, e.g. to send stream clients a reconnect hint.
+type Drainer interface {
+	Drain(ctx context.Context)
+}
+
+// Failer is implemented by components whose background work can fail after
+// Start has returned. An error on the channel shuts the app down.
+type Failer interface {
+	Failed() <-chan error
+}
+
+type entry struct {
+	name string
+	c    Component
+	deps []string
+}
+
+// App starts components in dependency order, waits for a signal or a
+// component failure, and stops them in reverse order.
+type App struct {
+	shutdownTimeout time.Duration
+	drainPeriod     time.Duration
+	entries         []entry
+	ready           atomic.Bool
+}
+
+// New returns an App that gives components shutdownTimeout in total to stop.
+func New(shutdownTimeout time.Duration) *App {
+	return &App{shutdownTimeout: shutdownTimeout}
+}
+
+// SetDrainPeriod makes Run keep serving for d after a shutdown signal, with
+// Ready reporting false, so load balancers stop routing new traffic before
+// components stop. Component failures still shut down immediately.
+func (a *App) SetDrainPeriod(d time.Duration) {
+	a.drainPeriod = d
+}
+
+// Ready reports whether every component has started and the app is not
+// shutting down.
+func (a *App) Ready() bool {
+	return a.ready.Load()
+}
+
+// ReadyHandler serves 200 while Ready and 503 otherwise, for load balancer
+// readiness probes.
+func (a *App) ReadyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !a.Ready() {
+			validate.WriteProblem(w, validate.Problem{Status: http.StatusServiceUnavailable, Detail: "not ready"})
+			return
+		}
+		w.Write([]byte("ok\n"))
+	})
+}
+
+// Add registers c under name. It is started after every component named in
+// dependsOn and stopped before them.
+func (a *App) Add(name string, c Component, dependsOn ...string) {
+	a.entries = append(a.entries, entry{name: name, c: c, deps: dependsOn})
+}
+
+// Run starts all components and blocks until ctx is cancelled, SIGINT or
+// SIGTERM arrives, or a component fails. It then stops every started
+// component and returns all start, failure, and stop errors joined. Once
+// shutdown begins, a second signal terminates the process at once.
+func (a *App) Run(ctx context.Context) error {
+	order, err := a.order()
+	if err != nil {
+		return err
+	}
+
+	sigCtx, stopSignals := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
+
+	// runCtx outlives the signal so components can drain against it.
+	runCtx, cancelRun := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelRun()
+
+	failed := make(chan error, len(order))
+	var errs []error
+	started := make([]entry, 0, len(order))
+
+	for _, e := range order {
+		if err := e.c.Start(runCtx); err != nil {
+			errs = append(errs, fmt.Errorf("starting %s: %w", e.name, err))
+			break
+		}
+		started = append(started, e)
+
+		if f, ok := e.c.(Failer); ok {
+			go forwardFailure(runCtx, e.name, f, failed)
+		}
+	}
+
+	if len(errs) == 0 {
+		a.ready.Store(true)
+
+		select {
+		case <-sigCtx.Done():
+			// Restore default handling so a second signal kills a shutdown
+			// that hangs.
+			stopSignals()
+			a.ready.Store(false)
+			a.drain(runCtx, started, failed)
+		case err := <-failed:
+			errs = append(errs, err)
+		}
+	}
+	stopSignals()
+	a.ready.Store(false)
+
+	stopCtx, cancelStop := context.WithTimeout(context.WithoutCancel(ctx), a.shutdownTimeout)
+	defer cancelStop()
+
+	for i := len(started) - 1; i >= 0; i-- {
+		e := started[i]
+		if err := e.c.Stop(stopCtx); err != nil {
+			errs = append(errs, fmt.Errorf("stopping %s: %w", e.name, err))
+		}
+	}
+
+	cancelRun()
+
+	for {
+		select {
+		case err := <-failed:
+			errs = append(errs, err)
+		default:
+			return errors.Join(errs...)
+		}
+	}
+}
+
+// drain tells Drainers the drain period has started and waits it out,
+// returning early if a component fails. A failure is left on failed for Run
+// to collect.
+func (a *App) drain(ctx context.Context, started []entry, failed chan error) {
+	if a.drainPeriod <= 0 {
+		return
+	}
+
+	for i := len(started) - 1; i >= 0; i-- {
+		if d, ok := started[i].c.(Drainer); ok {
+			d.Drain(ctx)
+		}
+	}
+
+	timer := time.NewTimer(a.drainPeriod)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case err := <-failed:
+		failed <- err
+	}
+}
+
+func forwardFailure(ctx context.Context, name string, f Failer, failed chan<- error) {
+	select {
+	case err, ok := <-f.Failed():
+		if ok && err != nil {
+			failed <- fmt.Errorf("%s failed: %w", name, err)
+		}
+	case <-ctx.Done():
+	}
+}
+
+// order sorts the components so each comes after its dependencies, keeping
+// registration order where dependencies allow.
+func (a *App) order() ([]entry, error) {
+	byName := make(map[string]entry, len(a.entries))
+	for _, e := range a.entries {
+		if _, ok := byName[e.name]; ok {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateComponent, e.name)
+		}
+		byName[e.name] = e
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int, len(a.entries))
+	order := make([]entry, 0, len(a.entries))
+
+	var visit func(e entry) error
+	visit = func(e entry) error {
+		switch state[e.name] {
+		case done:
+			return nil
+		case visiting:
+			return fmt.Errorf("%w: through %s", ErrDependencyCycle, e.name)
+		}
+
+		state[e.name] = visiting
+		for _, dep := range e.deps {
+			d, ok := byName[dep]
+			if !ok {
+				return fmt.Errorf("%w: %s depends on %s", ErrUnknownDependency, e.name, dep)
+			}
+			if err := visit(d); err != nil {
+				return err
+			}
+		}
+		state[e.name] = done
+		order = append(order, e)
+
+		return nil
+	}
+
+	for _, e := range a.entries {
+		if err := visit(e); err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+}
@@ -0,0 +1,223 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) add(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.events...)
+}
+
+type fakeComponent struct {
+	name     string
+	rec      *recorder
+	startErr error
+	stopErr  error
+	stopWait time.Duration
+}
+
+func (f *fakeComponent) Start(context.Context) error {
+	f.rec.add("start " + f.name)
+	return f.startErr
+}
+
+func (f *fakeComponent) Stop(ctx context.Context) error {
+	if f.stopWait > 0 {
+		select {
+		case <-time.After(f.stopWait):
+		case <-ctx.Done():
+			f.rec.add("timeout " + f.name)
+			return ctx.Err()
+		}
+	}
+	f.rec.add("stop " + f.name)
+	return f.stopErr
+}
+
+type drainingComponent struct {
+	fakeComponent
+}
+
+func (d *drainingComponent) Drain(context.Context) {
+	d.rec.add("drain " + d.name)
+}
+
+func TestRun(t *testing.T) {
+	t.Run("starts in dependency order and stops in reverse", func(t *testing.T) {
+		rec := &recorder{}
+		a := New(time.Second)
+		a.Add("server", &fakeComponent{name: "server", rec: rec}, "aggregator", "store")
+		a.Add("aggregator", &fakeComponent{name: "aggregator", rec: rec}, "store")
+		a.Add("store", &fakeComponent{name: "store", rec: rec})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if err := a.Run(ctx); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+
+		want := []string{
+			"start store", "start aggregator", "start server",
+			"stop server", "stop aggregator", "stop store",
+		}
+		if got := rec.get(); !reflect.DeepEqual(got, want) {
+			t.Errorf("expected events %v, got %v", want, got)
+		}
+	})
+
+	t.Run("start failure stops started components", func(t *testing.T) {
+		rec := &recorder{}
+		errBoom := errors.New("boom")
+		a := New(time.Second)
+		a.Add("store", &fakeComponent{name: "store", rec: rec})
+		a.Add("ingest", &fakeComponent{name: "ingest", rec: rec, startErr: errBoom}, "store")
+		a.Add("server", &fakeComponent{name: "server", rec: rec}, "ingest")
+
+		err := a.Run(context.Background())
+		if !errors.Is(err, errBoom) {
+			t.Fatalf("expected error %v, got: %v", errBoom, err)
+		}
+
+		want := []string{"start store", "start ingest", "stop store"}
+		if got := rec.get(); !reflect.DeepEqual(got, want) {
+			t.Errorf("expected events %v, got %v", want, got)
+		}
+	})
+
+	t.Run("component failure triggers shutdown", func(t *testing.T) {
+		rec := &recorder{}
+		errLost := errors.New("upstream lost")
+		a := New(time.Second)
+		a.Add("store", &fakeComponent{name: "store", rec: rec})
+		a.Add("ingest", NewBackground(func(ctx context.Context) error {
+			return errLost
+		}), "store")
+
+		err := a.Run(context.Background())
+		if !errors.Is(err, errLost) {
+			t.Fatalf("expected error %v, got: %v", errLost, err)
+		}
+		if got := rec.get(); !reflect.DeepEqual(got, []string{"start store", "stop store"}) {
+			t.Errorf("expected store to be stopped, got %v", got)
+		}
+	})
+
+	t.Run("shutdown timeout and stop errors are aggregated", func(t *testing.T) {
+		rec := &recorder{}
+		errFlush := errors.New("flush failed")
+		a := New(20 * time.Millisecond)
+		a.Add("aggregator", &fakeComponent{name: "aggregator", rec: rec, stopErr: errFlush})
+		a.Add("server", &fakeComponent{name: "server", rec: rec, stopWait: time.Second}, "aggregator")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := a.Run(ctx)
+		if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, errFlush) {
+			t.Fatalf("expected deadline and flush errors, got: %v", err)
+		}
+		if got := rec.get(); got[2] != "timeout server" {
+			t.Errorf("expected server to hit the shutdown deadline, got %v", got)
+		}
+	})
+
+	t.Run("drains before stopping", func(t *testing.T) {
+		rec := &recorder{}
+		a := New(time.Second)
+		a.SetDrainPeriod(50 * time.Millisecond)
+		a.Add("server", &drainingComponent{fakeComponent{name: "server", rec: rec}})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() { done <- a.Run(ctx) }()
+
+		for !a.Ready() {
+			time.Sleep(time.Millisecond)
+		}
+		probe := httptest.NewRecorder()
+		a.ReadyHandler().ServeHTTP(probe, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		if probe.Code != http.StatusOK {
+			t.Errorf("expected ready probe to pass, got %d", probe.Code)
+		}
+
+		cancel()
+		time.Sleep(20 * time.Millisecond)
+
+		probe = httptest.NewRecorder()
+		a.ReadyHandler().ServeHTTP(probe, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		if probe.Code != http.StatusServiceUnavailable {
+			t.Errorf("expected ready probe to fail while draining, got %d", probe.Code)
+		}
+		if got := rec.get(); !reflect.DeepEqual(got, []string{"start server", "drain server"}) {
+			t.Errorf("expected server to be draining but not stopped, got %v", got)
+		}
+
+		if err := <-done; err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if got := rec.get(); got[len(got)-1] != "stop server" {
+			t.Errorf("expected server to stop after draining, got %v", got)
+		}
+	})
+
+	tests := []struct {
+		name    string
+		setup   func(a *App)
+		wantErr error
+	}{
+		{
+			name: "unknown dependency",
+			setup: func(a *App) {
+				a.Add("server", &fakeComponent{rec: &recorder{}}, "store")
+			},
+			wantErr: ErrUnknownDependency,
+		},
+		{
+			name: "cycle",
+			setup: func(a *App) {
+				a.Add("a", &fakeComponent{rec: &recorder{}}, "b")
+				a.Add("b", &fakeComponent{rec: &recorder{}}, "a")
+			},
+			wantErr: ErrDependencyCycle,
+		},
+		{
+			name: "duplicate",
+			setup: func(a *App) {
+				a.Add("a", &fakeComponent{rec: &recorder{}})
+				a.Add("a", &fakeComponent{rec: &recorder{}})
+			},
+			wantErr: ErrDuplicateComponent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := New(time.Second)
+			tt.setup(a)
+
+			if err := a.Run(context.Background()); !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected error %v, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
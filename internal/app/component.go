@@ -0,0 +1,125 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	"marketflash/internal/config"
+)
+
+// Background adapts a blocking loop into a Component. The loop runs until its
+// context is cancelled by Stop; returning an error before that fails the app.
+type Background struct {
+	run    func(ctx context.Context) error
+	cancel context.CancelFunc
+	done   chan struct{}
+	failed chan error
+}
+
+// NewBackground returns a Component running fn in its own goroutine.
+func NewBackground(fn func(ctx context.Context) error) *Background {
+	return &Background{
+		run:    fn,
+		done:   make(chan struct{}),
+		failed: make(chan error, 1),
+	}
+}
+
+func (b *Background) Start(ctx context.Context) error {
+	ctx, b.cancel = context.WithCancel(ctx)
+
+	go func() {
+		defer close(b.done)
+		if err := b.run(ctx); err != nil && ctx.Err() == nil {
+			b.failed <- err
+		}
+	}()
+
+	return nil
+}
+
+// Stop cancels the loop and waits for it to return or for the shutdown
+// deadline.
+func (b *Background) Stop(ctx context.Context) error {
+	b.cancel()
+
+	select {
+	case <-b.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *Background) Failed() <-chan error {
+	return b.failed
+}
+
+// HTTPServer runs an *http.Server as a Component. Start binds the listeners,
+// so dependents only start once the ports are open; Stop stops accepting
+// connections and waits for in-flight requests to finish.
+type HTTPServer struct {
+	srv    *http.Server
+	listen func(ctx context.Context) ([]net.Listener, error)
+	lns    []net.Listener
+	failed chan error
+}
+
+// NewHTTPServer returns a Component serving srv on srv.Addr.
+func NewHTTPServer(srv *http.Server) *HTTPServer {
+	return NewHTTPServerListeners(srv, []config.ListenerConfig{{Type: "tcp", Address: srv.Addr}})
+}
+
+// NewHTTPServerListeners returns a Component serving srv on every listener
+// in listeners instead of srv.Addr; see Listen.
+func NewHTTPServerListeners(srv *http.Server, listeners []config.ListenerConfig) *HTTPServer {
+	return &HTTPServer{
+		srv: srv,
+		listen: func(ctx context.Context) ([]net.Listener, error) {
+			return Listen(ctx, listeners)
+		},
+		failed: make(chan error, 1),
+	}
+}
+
+func (h *HTTPServer) Start(ctx context.Context) error {
+	lns, err := h.listen(ctx)
+	if err != nil {
+		return err
+	}
+
+	h.lns = lns
+	h.srv.BaseContext = func(net.Listener) context.Context { return ctx }
+
+	for _, ln := range lns {
+		go func() {
+			if err := h.srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+				select {
+				case h.failed <- err:
+				default:
+				}
+			}
+		}()
+	}
+
+	return nil
+}
+
+// Addr returns the first bound address, which differs from srv.Addr when it
+// asked for port 0. It is nil before Start.
+func (h *HTTPServer) Addr() net.Addr {
+	if len(h.lns) == 0 {
+		return nil
+	}
+	return h.lns[0].Addr()
+}
+
+func (h *HTTPServer) Stop(ctx context.Context) error {
+	return h.srv.Shutdown(ctx)
+}
+
+func (h *HTTPServer) Failed() <-chan error {
+	return h.failed
+}
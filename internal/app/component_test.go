@@ -0,0 +1,86 @@
+package app
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestBackground(t *testing.T) {
+	stopped := make(chan struct{})
+	b := NewBackground(func(ctx context.Context) error {
+		<-ctx.Done()
+		close(stopped)
+		return ctx.Err()
+	})
+
+	if err := b.Start(context.Background()); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if err := b.Stop(context.Background()); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	<-stopped
+	select {
+	case err := <-b.Failed():
+		t.Errorf("expected cancellation not to count as failure, got: %v", err)
+	default:
+	}
+}
+
+func TestHTTPServer(t *testing.T) {
+	release := make(chan struct{})
+	srv := &http.Server{
+		Addr: "127.0.0.1:0",
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+			io.WriteString(w, "done")
+		}),
+	}
+	h := NewHTTPServer(srv)
+
+	if err := h.Start(context.Background()); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	result := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + h.Addr().String())
+		if err != nil {
+			result <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		result <- string(body)
+	}()
+
+	// Give the request time to reach the handler before shutting down.
+	time.Sleep(50 * time.Millisecond)
+
+	stopErr := make(chan error, 1)
+	go func() { stopErr <- h.Stop(context.Background()) }()
+
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	if got := <-result; got != "done" {
+		t.Errorf("expected in-flight request to finish, got %q", got)
+	}
+	if err := <-stopErr; err != nil {
+		t.Errorf("expected clean shutdown, got: %v", err)
+	}
+
+	select {
+	case err := <-h.Failed():
+		t.Errorf("expected shutdown not to count as failure, got: %v", err)
+	default:
+	}
+
+	if _, err := http.Get("http://" + h.Addr().String()); err == nil {
+		t.Errorf("expected listener to be closed after stop")
+	}
+}
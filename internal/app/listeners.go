@@ -0,0 +1,214 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"marketflash/internal/config"
+)
+
+var (
+	ErrNoSystemdSockets = errors.New("no sockets passed by systemd")
+	ErrUnknownListener  = errors.New("unknown listener type")
+	ErrNoReusePort      = errors.New("SO_REUSEPORT is not supported on this platform")
+	ErrSocketInUse      = errors.New("unix socket is in use by another process")
+)
+
+// listenFDsStart is the first file descriptor systemd passes, per
+// sd_listen_fds(3). Tests move it to descriptors they own.
+var listenFDsStart = 3
+
+// Listen opens a listener for each of cfgs. On error every listener opened
+// so far is closed.
+func Listen(ctx context.Context, cfgs []config.ListenerConfig) ([]net.Listener, error) {
+	var (
+		lns     []net.Listener
+		systemd map[string][]net.Listener // by FileDescriptorName, taken lazily
+	)
+
+	fail := func(err error) ([]net.Listener, error) {
+		for _, ln := range lns {
+			ln.Close()
+		}
+		for _, rest := range systemd {
+			for _, ln := range rest {
+				ln.Close()
+			}
+		}
+		return nil, err
+	}
+
+	for _, cfg := range cfgs {
+		switch cfg.Type {
+		case "tcp":
+			var lc net.ListenConfig
+			if cfg.ReusePort {
+				lc.Control = reusePort
+			}
+			ln, err := lc.Listen(ctx, "tcp", cfg.Address)
+			if err != nil {
+				return fail(err)
+			}
+			lns = append(lns, ln)
+		case "unix":
+			ln, err := listenUnix(ctx, cfg.Address)
+			if err != nil {
+				return fail(err)
+			}
+			lns = append(lns, ln)
+		case "systemd":
+			if systemd == nil {
+				var err error
+				if systemd, err = systemdListeners(); err != nil {
+					return fail(err)
+				}
+			}
+			taken, err := takeSystemd(systemd, cfg.Address)
+			if err != nil {
+				return fail(err)
+			}
+			lns = append(lns, taken...)
+		default:
+			return fail(fmt.Errorf("%w: %q", ErrUnknownListener, cfg.Type))
+		}
+	}
+
+	// Sockets systemd passed that no listener asked for are not served.
+	for _, rest := range systemd {
+		for _, ln := range rest {
+			ln.Close()
+		}
+	}
+
+	return lns, nil
+}
+
+// listenUnix listens on path, replacing a socket left behind by a process
+// that did not shut down cleanly. A socket another process still accepts
+// connections on is left alone and ErrSocketInUse returned; overlapping
+// restarts need a systemd socket instead. Any other file at path is an error.
+func listenUnix(ctx context.Context, path string) (net.Listener, error) {
+	if fi, err := os.Lstat(path); err == nil {
+		if fi.Mode().Type() != fs.ModeSocket {
+			return nil, fmt.Errorf("listen unix %s: file exists and is not a socket", path)
+		}
+
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "unix", path)
+		switch {
+		case err == nil:
+			conn.Close()
+			return nil, fmt.Errorf("%w: %s", ErrSocketInUse, path)
+		case !errors.Is(err, syscall.ECONNREFUSED):
+			return nil, err
+		}
+
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+
+	var lc net.ListenConfig
+	ln, err := lc.Listen(ctx, "unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := os.Lstat(path)
+	if err != nil {
+		ln.Close()
+		return nil, err
+	}
+
+	ul := ln.(*net.UnixListener)
+	ul.SetUnlinkOnClose(false)
+	return &unixListener{UnixListener: ul, path: path, file: fi}, nil
+}
+
+// unixListener removes its socket file on Close only while path is still
+// the socket it created, so it cannot delete a socket another process has
+// since bound there.
+type unixListener struct {
+	*net.UnixListener
+	path string
+	file os.FileInfo
+}
+
+func (l *unixListener) Close() error {
+	err := l.UnixListener.Close()
+	if fi, serr := os.Lstat(l.path); serr == nil && os.SameFile(fi, l.file) {
+		os.Remove(l.path)
+	}
+	return err
+}
+
+// systemdListeners returns the sockets passed by systemd socket activation,
+// keyed by FileDescriptorName. The environment variables are unset so child
+// processes do not inherit them.
+func systemdListeners() (map[string][]net.Listener, error) {
+	defer func() {
+		os.Unsetenv("LISTEN_PID")
+		os.Unsetenv("LISTEN_FDS")
+		os.Unsetenv("LISTEN_FDNAMES")
+	}()
+
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, ErrNoSystemdSockets
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n < 1 {
+		return nil, ErrNoSystemdSockets
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+
+	byName := make(map[string][]net.Listener)
+	for i := range n {
+		name := "unknown"
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+
+		f := os.NewFile(uintptr(listenFDsStart+i), name)
+		ln, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			for _, lns := range byName {
+				for _, ln := range lns {
+					ln.Close()
+				}
+			}
+			return nil, fmt.Errorf("systemd socket %s: %w", name, err)
+		}
+		byName[name] = append(byName[name], ln)
+	}
+
+	return byName, nil
+}
+
+// takeSystemd removes and returns the sockets called name from available, or
+// all of them when name is empty.
+func takeSystemd(available map[string][]net.Listener, name string) ([]net.Listener, error) {
+	var taken []net.Listener
+	if name == "" {
+		for n, lns := range available {
+			taken = append(taken, lns...)
+			delete(available, n)
+		}
+	} else {
+		taken = available[name]
+		delete(available, name)
+	}
+
+	if len(taken) == 0 {
+		return nil, fmt.Errorf("%w: %q", ErrNoSystemdSockets, name)
+	}
+	return taken, nil
+}
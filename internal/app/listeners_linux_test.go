@@ -0,0 +1,31 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"marketflash/internal/config"
+)
+
+func TestListenReusePort(t *testing.T) {
+	cfg := []config.ListenerConfig{{Type: "tcp", Address: "127.0.0.1:0", ReusePort: true}}
+	old, err := Listen(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	defer old[0].Close()
+
+	// The upgraded binary binds the same port while the old one still
+	// serves.
+	cfg[0].Address = old[0].Addr().String()
+	upgraded, err := Listen(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("expected second bind to succeed, got: %v", err)
+	}
+	upgraded[0].Close()
+
+	cfg[0].ReusePort = false
+	if _, err := Listen(context.Background(), cfg); err == nil {
+		t.Error("expected bind without reuse_port to fail")
+	}
+}
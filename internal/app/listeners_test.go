@@ -0,0 +1,95 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"marketflash/internal/config"
+)
+
+func TestListenUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.sock")
+
+	// A socket left behind by a crashed process.
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})}
+	h := NewHTTPServerListeners(srv, []config.ListenerConfig{{Type: "unix", Address: path}})
+	if err := h.Start(context.Background()); err != nil {
+		t.Fatalf("expected stale socket to be replaced, got: %v", err)
+	}
+	defer h.Stop(context.Background())
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		},
+	}}
+	resp, err := client.Get("http://unix/")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("expected 204, got %d", resp.StatusCode)
+	}
+
+	if _, err := Listen(context.Background(), []config.ListenerConfig{{Type: "unix", Address: path}}); !errors.Is(err, ErrSocketInUse) {
+		t.Errorf("expected error %v for a live socket, got: %v", ErrSocketInUse, err)
+	}
+
+	regular := filepath.Join(t.TempDir(), "not-a-socket")
+	if err := os.WriteFile(regular, nil, 0o600); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if _, err := Listen(context.Background(), []config.ListenerConfig{{Type: "unix", Address: regular}}); err == nil {
+		t.Error("expected an error for a regular file in the way")
+	}
+}
+
+func TestUnixListenerCloseKeepsReplacedSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.sock")
+
+	lns, err := Listen(context.Background(), []config.ListenerConfig{{Type: "unix", Address: path}})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	// Another process replaces the socket before this one shuts down.
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	next, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	defer next.Close()
+
+	lns[0].Close()
+	if _, err := os.Lstat(path); err != nil {
+		t.Errorf("expected the replacement socket to survive, got: %v", err)
+	}
+
+	next.Close()
+	lns, err = Listen(context.Background(), []config.ListenerConfig{{Type: "unix", Address: path}})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	lns[0].Close()
+	if _, err := os.Lstat(path); !os.IsNotExist(err) {
+		t.Errorf("expected the socket to be removed on close, got: %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+//go:build unix
+
+package app
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+
+	"marketflash/internal/config"
+)
+
+func TestListenSystemd(t *testing.T) {
+	passed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	defer passed.Close()
+
+	// Hand Listen a descriptor of its own, as systemd would.
+	f, err := passed.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	fd, err := syscall.Dup(int(f.Fd()))
+	f.Close()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	start := listenFDsStart
+	listenFDsStart = fd
+	t.Cleanup(func() { listenFDsStart = start })
+
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	t.Setenv("LISTEN_FDS", "1")
+	t.Setenv("LISTEN_FDNAMES", "http")
+
+	lns, err := Listen(context.Background(), []config.ListenerConfig{{Type: "systemd", Address: "http"}})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	defer lns[0].Close()
+
+	if got := lns[0].Addr().String(); got != passed.Addr().String() {
+		t.Errorf("expected inherited socket on %s, got %s", passed.Addr(), got)
+	}
+	if _, ok := os.LookupEnv("LISTEN_FDS"); ok {
+		t.Error("expected LISTEN_FDS to be unset")
+	}
+
+	// The variables are consumed, so a second activation finds nothing.
+	_, err = Listen(context.Background(), []config.ListenerConfig{{Type: "systemd"}})
+	if !errors.Is(err, ErrNoSystemdSockets) {
+		t.Errorf("expected ErrNoSystemdSockets, got: %v", err)
+	}
+}
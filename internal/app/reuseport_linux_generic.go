@@ -0,0 +1,8 @@
+//go:build linux && !(mips || mipsle || mips64 || mips64le || sparc64)
+
+package app
+
+// soReusePort is SO_REUSEPORT, which package syscall does not define for
+// every Linux architecture. MIPS and SPARC use a different value; see
+// reuseport_linux_mipsx.go.
+const soReusePort = 0xf
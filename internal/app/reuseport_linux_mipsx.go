@@ -0,0 +1,7 @@
+//go:build linux && (mips || mipsle || mips64 || mips64le || sparc64)
+
+package app
+
+// soReusePort is SO_REUSEPORT on MIPS and SPARC, which number socket options
+// differently from the other Linux architectures.
+const soReusePort = 0x200
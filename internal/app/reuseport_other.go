@@ -0,0 +1,9 @@
+//go:build !linux
+
+package app
+
+import "syscall"
+
+func reusePort(_, _ string, _ syscall.RawConn) error {
+	return ErrNoReusePort
+}
@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"marketflash/internal/validate"
+)
+
+type issueRequest struct {
+	Name    string  `json:"name"`
+	Scopes  []Scope `json:"scopes"`
+	Sandbox bool    `json:"sandbox"`
+}
+
+type issueResponse struct {
+	Key
+	Token string `json:"token"`
+}
+
+// AdminHandler serves key management under /admin/keys:
+//
+//	GET    /admin/keys       list keys
+//	POST   /admin/keys       issue a key, returning its token once; set
+//	                         "sandbox": true for a sandbox key
+//	DELETE /admin/keys/{id}  revoke a key
+//
+// Every route requires an authenticated principal with the admin scope.
+func (m *Manager) AdminHandler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /admin/keys", m.handleList)
+	mux.HandleFunc("POST /admin/keys", m.handleIssue)
+	mux.HandleFunc("DELETE /admin/keys/{id}", m.handleRevoke)
+
+	return m.Middleware(RequireScope(ScopeAdmin)(mux))
+}
+
+func (m *Manager) handleList(w http.ResponseWriter, r *http.Request) {
+	keys, err := m.List(r.Context())
+	if err != nil {
+		validate.WriteStatus(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, keys)
+}
+
+func (m *Manager) handleIssue(w http.ResponseWriter, r *http.Request) {
+	var req issueRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		validate.WriteError(w, fmt.Errorf("%w: %w", validate.ErrInvalidBody, err))
+		return
+	}
+
+	issue := m.Issue
+	if req.Sandbox {
+		issue = m.IssueSandbox
+	}
+
+	token, key, err := issue(r.Context(), req.Name, req.Scopes)
+	switch {
+	case errors.Is(err, ErrMissingName), errors.Is(err, ErrNoScopes), errors.Is(err, ErrUnknownScope), errors.Is(err, ErrSandboxAdmin):
+		validate.WriteStatus(w, http.StatusBadRequest, err)
+		return
+	case err != nil:
+		validate.WriteStatus(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, issueResponse{Key: key, Token: token})
+}
+
+func (m *Manager) handleRevoke(w http.ResponseWriter, r *http.Request) {
+	err := m.Revoke(r.Context(), r.PathValue("id"))
+	switch {
+	case errors.Is(err, ErrKeyNotFound):
+		validate.WriteStatus(w, http.StatusNotFound, err)
+		return
+	case err != nil:
+		validate.WriteStatus(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAdminHandler(t *testing.T) {
+	m := NewManager(NewMemoryStore(), "root-key")
+	handler := m.AdminHandler()
+
+	do := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do(http.MethodGet, "/admin/keys", "", ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without key, got %d", rec.Code)
+	}
+
+	rec := do(http.MethodPost, "/admin/keys", "root-key", `{"name":"reader","scopes":["read-quotes"]}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body)
+	}
+
+	var issued issueResponse
+	if err := json.NewDecoder(rec.Body).Decode(&issued); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if issued.Token == "" || issued.ID == "" {
+		t.Fatalf("expected token and id in response, got: %+v", issued)
+	}
+
+	if rec := do(http.MethodGet, "/admin/keys", issued.Token, ""); rec.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for non-admin key, got %d", rec.Code)
+	}
+
+	rec = do(http.MethodPost, "/admin/keys", "root-key", `{"name":"integrator","scopes":["read-quotes"],"sandbox":true}`)
+	if rec.Code != http.StatusCreated || !strings.Contains(rec.Body.String(), `"token":"mf_test_`) {
+		t.Errorf("expected sandbox key, got %d: %s", rec.Code, rec.Body)
+	}
+	if rec := do(http.MethodPost, "/admin/keys", "root-key", `{"name":"x","scopes":["admin"],"sandbox":true}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for admin sandbox key, got %d", rec.Code)
+	}
+
+	rec = do(http.MethodPost, "/admin/keys", "root-key", `{"name":"bad","scopes":["trade"]}`)
+	if rec.Code != http.StatusBadRequest || rec.Header().Get("Content-Type") != "application/problem+json" {
+		t.Errorf("expected a 400 problem for unknown scope, got %d: %s", rec.Code, rec.Body)
+	}
+
+	rec = do(http.MethodGet, "/admin/keys", "root-key", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if strings.Contains(rec.Body.String(), issued.Token) {
+		t.Errorf("expected listing not to expose tokens")
+	}
+
+	if rec := do(http.MethodDelete, "/admin/keys/"+issued.ID, "root-key", ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", rec.Code)
+	}
+	if rec := do(http.MethodDelete, "/admin/keys/missing", "root-key", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/admin/keys", issued.Token, ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for revoked key, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,215 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidKey   = errors.New("invalid api key")
+	ErrKeyRevoked   = errors.New("api key has been revoked")
+	ErrKeyNotFound  = errors.New("api key not found")
+	ErrUnknownScope = errors.New("unknown scope")
+	ErrMissingName  = errors.New("api key name is required")
+	ErrNoScopes     = errors.New("at least one scope is required")
+	ErrSandboxAdmin = errors.New("sandbox keys cannot have the admin scope")
+)
+
+// Scope grants access to a group of API operations.
+type Scope string
+
+const (
+	ScopeReadQuotes   Scope = "read-quotes"
+	ScopeManageAlerts Scope = "manage-alerts"
+	ScopeAdmin        Scope = "admin"
+)
+
+var validScopes = []Scope{ScopeReadQuotes, ScopeManageAlerts, ScopeAdmin}
+
+// tokenPrefix marks marketflash keys so they are recognisable in logs and
+// secret scanners; sandbox keys carry sandboxTokenPrefix instead.
+const (
+	tokenPrefix        = "mf_"
+	sandboxTokenPrefix = "mf_test_"
+)
+
+// Key is a stored client API key. Only the SHA-256 hash of the token is kept;
+// the token itself is shown once, when the key is issued.
+type Key struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Hash      string     `json:"-"`
+	Scopes    []Scope    `json:"scopes"`
+	Sandbox   bool       `json:"sandbox"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Principal is the authenticated caller attached to a request context.
+// Sandbox principals are served synthetic data; see Sandboxed.
+type Principal struct {
+	KeyID   string
+	Name    string
+	Scopes  []Scope
+	Sandbox bool
+}
+
+// HasScope reports whether p was granted scope. Admin implies every scope.
+func (p Principal) HasScope(scope Scope) bool {
+	return slices.Contains(p.Scopes, ScopeAdmin) || slices.Contains(p.Scopes, scope)
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying p.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFrom returns the principal attached by the auth middleware.
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// Manager issues, revokes, and authenticates API keys.
+type Manager struct {
+	store     Store
+	bootstrap string
+	now       func() time.Time
+}
+
+// NewManager returns a Manager backed by store. A non-empty bootstrapKey (the
+// api_key config value) is accepted as an admin credential so the first keys
+// can be issued before any exist in the store.
+func NewManager(store Store, bootstrapKey string) *Manager {
+	return &Manager{
+		store:     store,
+		bootstrap: bootstrapKey,
+		now:       time.Now,
+	}
+}
+
+// Issue creates a key with the given scopes and returns its token, which is
+// not stored and cannot be recovered later.
+func (m *Manager) Issue(ctx context.Context, name string, scopes []Scope) (string, Key, error) {
+	return m.issue(ctx, name, scopes, false)
+}
+
+// IssueSandbox creates a sandbox key, whose requests are served from the
+// sandbox environment whatever endpoint they call. Sandbox tokens start with
+// "mf_test_" and cannot have the admin scope.
+func (m *Manager) IssueSandbox(ctx context.Context, name string, scopes []Scope) (string, Key, error) {
+	if slices.Contains(scopes, ScopeAdmin) {
+		return "", Key{}, ErrSandboxAdmin
+	}
+	return m.issue(ctx, name, scopes, true)
+}
+
+func (m *Manager) issue(ctx context.Context, name string, scopes []Scope, sandbox bool) (string, Key, error) {
+	if strings.TrimSpace(name) == "" {
+		return "", Key{}, ErrMissingName
+	}
+
+	if len(scopes) == 0 {
+		return "", Key{}, ErrNoScopes
+	}
+
+	for _, s := range scopes {
+		if !slices.Contains(validScopes, s) {
+			return "", Key{}, fmt.Errorf("%w: %q", ErrUnknownScope, s)
+		}
+	}
+
+	id, err := randomString(8)
+	if err != nil {
+		return "", Key{}, err
+	}
+
+	secret, err := randomString(32)
+	if err != nil {
+		return "", Key{}, err
+	}
+
+	prefix := tokenPrefix
+	if sandbox {
+		prefix = sandboxTokenPrefix
+	}
+
+	token := prefix + secret
+	key := Key{
+		ID:        id,
+		Name:      name,
+		Hash:      hashToken(token),
+		Scopes:    slices.Clone(scopes),
+		Sandbox:   sandbox,
+		CreatedAt: m.now().UTC(),
+	}
+
+	if err := m.store.Create(ctx, key); err != nil {
+		return "", Key{}, err
+	}
+
+	return token, key, nil
+}
+
+// Revoke marks the key with id as revoked. Revoked keys stay listed for
+// auditing but no longer authenticate.
+func (m *Manager) Revoke(ctx context.Context, id string) error {
+	return m.store.Revoke(ctx, id, m.now().UTC())
+}
+
+// List returns all keys, including revoked ones.
+func (m *Manager) List(ctx context.Context) ([]Key, error) {
+	return m.store.List(ctx)
+}
+
+// Authenticate resolves token to the principal it was issued to.
+func (m *Manager) Authenticate(ctx context.Context, token string) (Principal, error) {
+	if token == "" {
+		return Principal{}, ErrInvalidKey
+	}
+
+	if m.bootstrap != "" && subtle.ConstantTimeCompare([]byte(token), []byte(m.bootstrap)) == 1 {
+		return Principal{KeyID: "bootstrap", Name: "bootstrap", Scopes: []Scope{ScopeAdmin}}, nil
+	}
+
+	key, err := m.store.GetByHash(ctx, hashToken(token))
+	if errors.Is(err, ErrKeyNotFound) {
+		return Principal{}, ErrInvalidKey
+	}
+	if err != nil {
+		return Principal{}, err
+	}
+
+	if key.RevokedAt != nil {
+		return Principal{}, ErrKeyRevoked
+	}
+
+	return Principal{KeyID: key.ID, Name: key.Name, Scopes: key.Scopes, Sandbox: key.Sandbox}, nil
+}
+
+// hashToken hashes a token for storage. Tokens carry 256 bits of randomness,
+// so a fast unsalted hash is sufficient; a slow KDF would only add latency to
+// every request.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating random bytes: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestManager(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("issue and authenticate", func(t *testing.T) {
+		m := NewManager(NewMemoryStore(), "")
+
+		token, key, err := m.Issue(ctx, "dashboard", []Scope{ScopeReadQuotes})
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if !strings.HasPrefix(token, tokenPrefix) {
+			t.Errorf("expected token prefix %q, got %q", tokenPrefix, token)
+		}
+		if key.Hash == token || strings.Contains(key.Hash, token) {
+			t.Errorf("expected token to be hashed at rest")
+		}
+
+		p, err := m.Authenticate(ctx, token)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if p.KeyID != key.ID || p.Name != "dashboard" {
+			t.Errorf("expected principal for key %s, got: %+v", key.ID, p)
+		}
+		if !p.HasScope(ScopeReadQuotes) || p.HasScope(ScopeManageAlerts) {
+			t.Errorf("expected only read-quotes scope, got: %v", p.Scopes)
+		}
+	})
+
+	t.Run("sandbox key", func(t *testing.T) {
+		m := NewManager(NewMemoryStore(), "")
+
+		token, key, err := m.IssueSandbox(ctx, "integrator", []Scope{ScopeReadQuotes})
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if !strings.HasPrefix(token, sandboxTokenPrefix) || !key.Sandbox {
+			t.Errorf("expected a sandbox key, got token %q and %+v", token, key)
+		}
+
+		p, err := m.Authenticate(ctx, token)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if !p.Sandbox {
+			t.Errorf("expected sandbox principal, got: %+v", p)
+		}
+
+		if _, _, err := m.IssueSandbox(ctx, "root", []Scope{ScopeAdmin}); !errors.Is(err, ErrSandboxAdmin) {
+			t.Errorf("expected error %v, got: %v", ErrSandboxAdmin, err)
+		}
+	})
+
+	t.Run("revoked key", func(t *testing.T) {
+		m := NewManager(NewMemoryStore(), "")
+
+		token, key, err := m.Issue(ctx, "old", []Scope{ScopeReadQuotes})
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if err := m.Revoke(ctx, key.ID); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+
+		if _, err := m.Authenticate(ctx, token); !errors.Is(err, ErrKeyRevoked) {
+			t.Errorf("expected error %v, got: %v", ErrKeyRevoked, err)
+		}
+		if err := m.Revoke(ctx, "missing"); !errors.Is(err, ErrKeyNotFound) {
+			t.Errorf("expected error %v, got: %v", ErrKeyNotFound, err)
+		}
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		m := NewManager(NewMemoryStore(), "")
+
+		if _, err := m.Authenticate(ctx, "mf_nope"); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("expected error %v, got: %v", ErrInvalidKey, err)
+		}
+		if _, err := m.Authenticate(ctx, ""); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("expected error %v, got: %v", ErrInvalidKey, err)
+		}
+	})
+
+	t.Run("bootstrap key is admin", func(t *testing.T) {
+		m := NewManager(NewMemoryStore(), "config-key")
+
+		p, err := m.Authenticate(ctx, "config-key")
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if !p.HasScope(ScopeAdmin) || !p.HasScope(ScopeManageAlerts) {
+			t.Errorf("expected bootstrap principal to have admin scope, got: %v", p.Scopes)
+		}
+	})
+
+	tests := []struct {
+		name    string
+		keyName string
+		scopes  []Scope
+		wantErr error
+	}{
+		{name: "missing name", keyName: " ", scopes: []Scope{ScopeAdmin}, wantErr: ErrMissingName},
+		{name: "no scopes", keyName: "k", scopes: nil, wantErr: ErrNoScopes},
+		{name: "unknown scope", keyName: "k", scopes: []Scope{"trade"}, wantErr: ErrUnknownScope},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewManager(NewMemoryStore(), "")
+
+			if _, _, err := m.Issue(ctx, tt.keyName, tt.scopes); !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected error %v, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
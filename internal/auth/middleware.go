@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"marketflash/internal/validate"
+)
+
+// TokenFrom extracts the API key from the X-API-Key header or a bearer
+// Authorization header.
+func TokenFrom(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+
+	return ""
+}
+
+// Middleware authenticates every request and attaches the principal to its
+// context. Requests without a valid key are rejected with 401.
+func (m *Manager) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, err := m.Authenticate(r.Context(), TokenFrom(r))
+
+		switch {
+		case errors.Is(err, ErrInvalidKey), errors.Is(err, ErrKeyRevoked):
+			w.Header().Set("WWW-Authenticate", `Bearer realm="marketflash"`)
+			validate.WriteStatus(w, http.StatusUnauthorized, err)
+			return
+		case err != nil:
+			validate.WriteStatus(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), p)))
+	})
+}
+
+// RequireScope rejects requests whose principal lacks scope with 403. It must
+// run after Middleware.
+func RequireScope(scope Scope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, ok := PrincipalFrom(r.Context())
+			if !ok {
+				validate.WriteStatus(w, http.StatusUnauthorized, ErrInvalidKey)
+				return
+			}
+
+			if !p.HasScope(scope) {
+				validate.WriteProblem(w, validate.Problem{Status: http.StatusForbidden, Detail: "missing scope " + string(scope)})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// EnvironmentHeader names the environment, "live" or "sandbox", that served a
+// request routed by Sandboxed.
+const EnvironmentHeader = "X-Marketflash-Environment"
+
+// Sandboxed sends requests from sandbox principals to sandbox and all others
+// to live, so sandbox keys never reach real data whatever endpoint they call.
+// sandbox should be the same routes wired to the simulator and stores of its
+// own. It must run after Middleware.
+func Sandboxed(live, sandbox http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, ok := PrincipalFrom(r.Context())
+		if !ok {
+			validate.WriteStatus(w, http.StatusUnauthorized, ErrInvalidKey)
+			return
+		}
+
+		if p.Sandbox {
+			w.Header().Set(EnvironmentHeader, "sandbox")
+			sandbox.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set(EnvironmentHeader, "live")
+		live.ServeHTTP(w, r)
+	})
+}
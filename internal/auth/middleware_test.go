@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSandboxed(t *testing.T) {
+	serve := func(body string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, body)
+		})
+	}
+	handler := Sandboxed(serve("live"), serve("sandbox"))
+
+	tests := []struct {
+		name      string
+		principal *Principal
+		wantCode  int
+		wantBody  string
+	}{
+		{name: "live key", principal: &Principal{KeyID: "k1"}, wantCode: http.StatusOK, wantBody: "live"},
+		{name: "sandbox key", principal: &Principal{KeyID: "k2", Sandbox: true}, wantCode: http.StatusOK, wantBody: "sandbox"},
+		{name: "unauthenticated", wantCode: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/quotes/AAPL", nil)
+			if tt.principal != nil {
+				req = req.WithContext(WithPrincipal(req.Context(), *tt.principal))
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d", tt.wantCode, rec.Code)
+			}
+			if tt.wantBody != "" && (rec.Body.String() != tt.wantBody || rec.Header().Get(EnvironmentHeader) != tt.wantBody) {
+				t.Errorf("expected %s environment, got %q with header %q", tt.wantBody, rec.Body, rec.Header().Get(EnvironmentHeader))
+			}
+		})
+	}
+}
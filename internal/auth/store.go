@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Store persists API keys. Implementations look keys up by token hash and
+// must never see plaintext tokens.
+type Store interface {
+	Create(ctx context.Context, key Key) error
+	GetByHash(ctx context.Context, hash string) (Key, error)
+	Revoke(ctx context.Context, id string, at time.Time) error
+	List(ctx context.Context) ([]Key, error)
+}
+
+// MemoryStore is an in-process Store, used in tests and development.
+type MemoryStore struct {
+	mu   sync.RWMutex
+	keys map[string]Key // by ID
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{keys: make(map[string]Key)}
+}
+
+func (s *MemoryStore) Create(_ context.Context, key Key) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys[key.ID] = key
+
+	return nil
+}
+
+func (s *MemoryStore) GetByHash(_ context.Context, hash string) (Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, k := range s.keys {
+		if k.Hash == hash {
+			return k, nil
+		}
+	}
+
+	return Key{}, ErrKeyNotFound
+}
+
+func (s *MemoryStore) Revoke(_ context.Context, id string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k, ok := s.keys[id]
+	if !ok {
+		return ErrKeyNotFound
+	}
+
+	if k.RevokedAt == nil {
+		k.RevokedAt = &at
+		s.keys[id] = k
+	}
+
+	return nil
+}
+
+func (s *MemoryStore) List(_ context.Context) ([]Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]Key, 0, len(s.keys))
+	for _, k := range s.keys {
+		keys = append(keys, k)
+	}
+
+	slices.SortFunc(keys, func(a, b Key) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	return keys, nil
+}
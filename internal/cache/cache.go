@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"marketflash/internal/config"
+)
+
+// Cache stores opaque values with a time to live. Implementations are safe for
+// concurrent use.
+type Cache interface {
+	// Get returns the value for key and whether it was present.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+	Close() error
+}
+
+// New returns a Redis-backed cache when cfg has a RedisURL and an in-process
+// cache otherwise.
+func New(cfg config.CacheConfig) (Cache, error) {
+	if cfg.RedisURL == "" {
+		return NewMemory(), nil
+	}
+	return NewRedis(cfg.RedisURL)
+}
+
+// QuoteKey is the cache key for the latest quote of symbol.
+func QuoteKey(symbol string) string {
+	return "quote:" + strings.ToUpper(symbol)
+}
+
+// CandlesKey is the cache key for the recent candles of symbol at interval.
+// It must be invalidated whenever a candle for that series closes.
+func CandlesKey(symbol, interval string) string {
+	return "candles:" + strings.ToUpper(symbol) + ":" + interval
+}
+
+// InvalidateCandles drops cached candles for symbol at interval. The
+// aggregator calls it on every candle close so readers never see a series
+// missing its newest bar.
+func InvalidateCandles(ctx context.Context, c Cache, symbol, interval string) error {
+	return c.Delete(ctx, CandlesKey(symbol, interval))
+}
+
+// GetOrLoad returns the cached value for key, or calls load and caches its
+// result for ttl. Values are stored as JSON. Cache errors are not fatal: a
+// failed read falls through to load and a failed write is ignored, so an
+// unavailable cache only costs latency.
+func GetOrLoad[T any](ctx context.Context, c Cache, key string, ttl time.Duration, load func(context.Context) (T, error)) (T, error) {
+	if data, ok, err := c.Get(ctx, key); err == nil && ok {
+		var v T
+		if err := json.Unmarshal(data, &v); err == nil {
+			return v, nil
+		}
+	}
+
+	v, err := load(ctx)
+	if err != nil {
+		return v, err
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v, fmt.Errorf("encoding cache value for %s: %w", key, err)
+	}
+	_ = c.Set(ctx, key, data, ttl)
+
+	return v, nil
+}
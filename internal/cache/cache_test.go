@@ -0,0 +1,114 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"marketflash/internal/config"
+)
+
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(0, 0)
+	m := NewMemory()
+	m.now = func() time.Time { return now }
+
+	if err := m.Set(ctx, "k", []byte("v"), time.Second); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	if v, ok, _ := m.Get(ctx, "k"); !ok || string(v) != "v" {
+		t.Fatalf("expected hit, got %q ok=%v", v, ok)
+	}
+
+	now = now.Add(time.Second)
+	if _, ok, _ := m.Get(ctx, "k"); ok {
+		t.Errorf("expected entry to expire")
+	}
+
+	m.Set(ctx, "a", []byte("1"), time.Minute)
+	m.Set(ctx, "b", []byte("2"), time.Minute)
+	m.Delete(ctx, "a", "b")
+	if len(m.entries) != 0 {
+		t.Errorf("expected delete to remove entries, have %d", len(m.entries))
+	}
+}
+
+func TestNew(t *testing.T) {
+	c, err := New(config.CacheConfig{})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if _, ok := c.(*Memory); !ok {
+		t.Errorf("expected memory cache without redis url, got %T", c)
+	}
+
+	c, err = New(config.CacheConfig{RedisURL: "redis://localhost:6379"})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if _, ok := c.(*Redis); !ok {
+		t.Errorf("expected redis cache with redis url, got %T", c)
+	}
+}
+
+func TestGetOrLoad(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemory()
+
+	type quote struct {
+		Symbol string  `json:"symbol"`
+		Price  float64 `json:"price"`
+	}
+
+	calls := 0
+	load := func(context.Context) (quote, error) {
+		calls++
+		return quote{Symbol: "AAPL", Price: 190.5}, nil
+	}
+
+	for range 3 {
+		q, err := GetOrLoad(ctx, c, QuoteKey("aapl"), time.Minute, load)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if q.Price != 190.5 {
+			t.Errorf("expected price 190.5, got %v", q.Price)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected one load, got %d", calls)
+	}
+
+	errLoad := errors.New("store down")
+	_, err := GetOrLoad(ctx, c, QuoteKey("msft"), time.Minute, func(context.Context) (quote, error) {
+		return quote{}, errLoad
+	})
+	if !errors.Is(err, errLoad) {
+		t.Errorf("expected error %v, got: %v", errLoad, err)
+	}
+	if _, ok, _ := c.Get(ctx, QuoteKey("msft")); ok {
+		t.Errorf("expected failed load not to be cached")
+	}
+}
+
+func TestInvalidateCandles(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemory()
+
+	c.Set(ctx, CandlesKey("btc-usd", "1m"), []byte("[]"), time.Minute)
+	c.Set(ctx, CandlesKey("btc-usd", "1h"), []byte("[]"), time.Minute)
+
+	if err := InvalidateCandles(ctx, c, "BTC-USD", "1m"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	if _, ok, _ := c.Get(ctx, CandlesKey("BTC-USD", "1m")); ok {
+		t.Errorf("expected 1m candles to be invalidated")
+	}
+	if _, ok, _ := c.Get(ctx, CandlesKey("BTC-USD", "1h")); !ok {
+		t.Errorf("expected 1h candles to be kept")
+	}
+}
@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// Memory is an in-process Cache used when Redis is not configured. Expired
+// entries are dropped lazily on access and during periodic sweeps on Set.
+type Memory struct {
+	mu        sync.Mutex
+	entries   map[string]memoryEntry
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+const memorySweepInterval = time.Minute
+
+// NewMemory returns an empty in-process cache.
+func NewMemory() *Memory {
+	return &Memory{
+		entries:   make(map[string]memoryEntry),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+func (m *Memory) Get(_ context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	if !m.now().Before(e.expiresAt) {
+		delete(m.entries, key)
+		return nil, false, nil
+	}
+
+	return e.value, true, nil
+}
+
+func (m *Memory) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+
+	if now.Sub(m.lastSweep) >= memorySweepInterval {
+		for k, e := range m.entries {
+			if !now.Before(e.expiresAt) {
+				delete(m.entries, k)
+			}
+		}
+		m.lastSweep = now
+	}
+
+	m.entries[key] = memoryEntry{
+		value:     append([]byte(nil), value...),
+		expiresAt: now.Add(ttl),
+	}
+
+	return nil
+}
+
+func (m *Memory) Delete(_ context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, k := range keys {
+		delete(m.entries, k)
+	}
+
+	return nil
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
@@ -0,0 +1,310 @@
+package cache
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrRedisURL    = errors.New("invalid redis url")
+	ErrRedisReply  = errors.New("redis error reply")
+	ErrRedisClosed = errors.New("redis cache is closed")
+)
+
+const (
+	redisMaxIdle     = 8
+	redisDialTimeout = 5 * time.Second
+	redisIOTimeout   = 2 * time.Second
+)
+
+// Redis is a Cache backed by a Redis server. It speaks the small subset of
+// RESP needed for GET, SET with expiry, and DEL over a pool of connections.
+type Redis struct {
+	addr     string
+	tls      bool
+	username string
+	password string
+	db       int
+	idle     chan *redisConn
+
+	mu     sync.Mutex // guards closed and returning connections to idle
+	closed bool
+}
+
+type redisConn struct {
+	net.Conn
+	r *bufio.Reader
+	w *bufio.Writer
+}
+
+// NewRedis returns a cache for rawURL, of the form
+// redis[s]://[[user]:password@]host[:port][/db]. Connections are opened
+// lazily, so an unreachable server surfaces on first use.
+func NewRedis(rawURL string) (*Redis, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrRedisURL, err)
+	}
+
+	if u.Scheme != "redis" && u.Scheme != "rediss" {
+		return nil, fmt.Errorf("%w: unsupported scheme %q", ErrRedisURL, u.Scheme)
+	}
+
+	r := &Redis{
+		addr: u.Host,
+		tls:  u.Scheme == "rediss",
+		idle: make(chan *redisConn, redisMaxIdle),
+	}
+
+	if u.Port() == "" {
+		r.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+
+	if u.User != nil {
+		r.username = u.User.Username()
+		r.password, _ = u.User.Password()
+	}
+
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		r.db, err = strconv.Atoi(db)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid db %q", ErrRedisURL, db)
+		}
+	}
+
+	return r, nil
+}
+
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := r.do(ctx, "GET", key)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if reply == nil {
+		return nil, false, nil
+	}
+
+	b, ok := reply.([]byte)
+	if !ok {
+		return nil, false, fmt.Errorf("redis GET %s: unexpected reply %T", key, reply)
+	}
+
+	return b, true, nil
+}
+
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	ms := strconv.FormatInt(max(ttl.Milliseconds(), 1), 10)
+	_, err := r.do(ctx, "SET", key, string(value), "PX", ms)
+	return err
+}
+
+func (r *Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	_, err := r.do(ctx, append([]string{"DEL"}, keys...)...)
+	return err
+}
+
+// Close closes idle connections. Connections in use are closed when they are
+// returned, and later calls fail with ErrRedisClosed.
+func (r *Redis) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closed = true
+	for {
+		select {
+		case c := <-r.idle:
+			c.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+func (r *Redis) do(ctx context.Context, args ...string) (any, error) {
+	c, err := r.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := c.roundTrip(ctx, args)
+	if err != nil && !errors.Is(err, ErrRedisReply) {
+		// The stream may be mid-reply; never reuse it.
+		c.Close()
+		return nil, err
+	}
+
+	r.release(c)
+
+	return reply, err
+}
+
+// release returns c to the idle pool, or closes it when the pool is full or
+// the cache is closed.
+func (r *Redis) release(c *redisConn) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		c.Close()
+		return
+	}
+
+	select {
+	case r.idle <- c:
+	default:
+		c.Close()
+	}
+}
+
+func (r *Redis) conn(ctx context.Context) (*redisConn, error) {
+	r.mu.Lock()
+	closed := r.closed
+	r.mu.Unlock()
+	if closed {
+		return nil, ErrRedisClosed
+	}
+
+	select {
+	case c := <-r.idle:
+		return c, nil
+	default:
+	}
+
+	dialer := &net.Dialer{Timeout: redisDialTimeout}
+
+	var (
+		nc  net.Conn
+		err error
+	)
+	if r.tls {
+		td := &tls.Dialer{NetDialer: dialer}
+		nc, err = td.DialContext(ctx, "tcp", r.addr)
+	} else {
+		nc, err = dialer.DialContext(ctx, "tcp", r.addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("dialing redis %s: %w", r.addr, err)
+	}
+
+	c := &redisConn{Conn: nc, r: bufio.NewReader(nc), w: bufio.NewWriter(nc)}
+
+	if r.password != "" {
+		args := []string{"AUTH", r.password}
+		if r.username != "" {
+			args = []string{"AUTH", r.username, r.password}
+		}
+		if _, err := c.roundTrip(ctx, args); err != nil {
+			c.Close()
+			return nil, fmt.Errorf("redis auth: %w", err)
+		}
+	}
+
+	if r.db != 0 {
+		if _, err := c.roundTrip(ctx, []string{"SELECT", strconv.Itoa(r.db)}); err != nil {
+			c.Close()
+			return nil, fmt.Errorf("redis select %d: %w", r.db, err)
+		}
+	}
+
+	return c, nil
+}
+
+func (c *redisConn) roundTrip(ctx context.Context, args []string) (any, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(redisIOTimeout)
+	}
+	if err := c.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(c.w, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(c.w, "$%d\r\n%s\r\n", len(a), a)
+	}
+	if err := c.w.Flush(); err != nil {
+		return nil, fmt.Errorf("writing redis command: %w", err)
+	}
+
+	return readReply(c.r)
+}
+
+// readReply decodes one RESP value: simple strings as string, integers as
+// int64, bulk strings as []byte, arrays as []any, and nil bulk/array as nil.
+func readReply(r *bufio.Reader) (any, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("reading redis reply: %w", err)
+	}
+
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("reading redis reply: empty line")
+	}
+
+	kind, rest := line[0], line[1:]
+
+	switch kind {
+	case '+':
+		return rest, nil
+	case '-':
+		return nil, fmt.Errorf("%w: %s", ErrRedisReply, rest)
+	case ':':
+		return strconv.ParseInt(rest, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(rest)
+		if err != nil {
+			return nil, fmt.Errorf("reading redis bulk length: %w", err)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, fmt.Errorf("reading redis bulk: %w", err)
+		}
+		return buf[:n], nil
+	case '*':
+		n, err := strconv.Atoi(rest)
+		if err != nil {
+			return nil, fmt.Errorf("reading redis array length: %w", err)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		// An error element still leaves the rest of the array on the wire;
+		// read it all so the connection stays in step for reuse.
+		items := make([]any, n)
+		var replyErr error
+		for i := range items {
+			items[i], err = readReply(r)
+			switch {
+			case errors.Is(err, ErrRedisReply):
+				replyErr = cmp.Or(replyErr, err)
+			case err != nil:
+				return nil, err
+			}
+		}
+		if replyErr != nil {
+			return nil, replyErr
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("reading redis reply: unknown type %q", kind)
+	}
+}
@@ -0,0 +1,216 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis serves GET, SET, DEL, AUTH, and SELECT from a map, recording
+// every command it receives.
+type fakeRedis struct {
+	mu       sync.Mutex
+	data     map[string]string
+	commands []string
+	password string
+}
+
+func startFakeRedis(t *testing.T, password string) (*fakeRedis, string) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	f := &fakeRedis{data: make(map[string]string), password: password}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+
+	return f, ln.Addr().String()
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	authed := f.password == ""
+
+	for {
+		reply, err := readReply(r)
+		if err != nil {
+			return
+		}
+
+		var args []string
+		for _, a := range reply.([]any) {
+			args = append(args, string(a.([]byte)))
+		}
+
+		f.mu.Lock()
+		f.commands = append(f.commands, strings.Join(args, " "))
+
+		var out string
+		switch {
+		case args[0] == "AUTH":
+			authed = args[len(args)-1] == f.password
+			out = "+OK\r\n"
+			if !authed {
+				out = "-WRONGPASS invalid password\r\n"
+			}
+		case !authed:
+			out = "-NOAUTH Authentication required.\r\n"
+		case args[0] == "SELECT":
+			out = "+OK\r\n"
+		case args[0] == "GET":
+			if v, ok := f.data[args[1]]; ok {
+				out = fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+			} else {
+				out = "$-1\r\n"
+			}
+		case args[0] == "SET":
+			f.data[args[1]] = args[2]
+			out = "+OK\r\n"
+		case args[0] == "DEL":
+			n := 0
+			for _, k := range args[1:] {
+				if _, ok := f.data[k]; ok {
+					delete(f.data, k)
+					n++
+				}
+			}
+			out = fmt.Sprintf(":%d\r\n", n)
+		default:
+			out = "-ERR unknown command\r\n"
+		}
+		f.mu.Unlock()
+
+		if _, err := conn.Write([]byte(out)); err != nil {
+			return
+		}
+	}
+}
+
+func (f *fakeRedis) received() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.commands...)
+}
+
+func TestRedis(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("get set delete", func(t *testing.T) {
+		f, addr := startFakeRedis(t, "secret")
+
+		c, err := NewRedis("redis://:secret@" + addr + "/2")
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		defer c.Close()
+
+		if _, ok, err := c.Get(ctx, "quote:AAPL"); err != nil || ok {
+			t.Fatalf("expected miss, got ok=%v err=%v", ok, err)
+		}
+
+		if err := c.Set(ctx, "quote:AAPL", []byte(`{"price":1}`), 1500*time.Millisecond); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+
+		v, ok, err := c.Get(ctx, "quote:AAPL")
+		if err != nil || !ok || string(v) != `{"price":1}` {
+			t.Fatalf("expected hit, got %q ok=%v err=%v", v, ok, err)
+		}
+
+		if err := c.Delete(ctx, "quote:AAPL"); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if _, ok, _ := c.Get(ctx, "quote:AAPL"); ok {
+			t.Errorf("expected miss after delete")
+		}
+
+		got := f.received()
+		want := []string{"AUTH secret", "SELECT 2", "GET quote:AAPL", `SET quote:AAPL {"price":1} PX 1500`}
+		for i, w := range want {
+			if i >= len(got) || got[i] != w {
+				t.Fatalf("expected commands to start with %q, got %q", want, got)
+			}
+		}
+		if n := strings.Count(strings.Join(got, "\n"), "AUTH"); n != 1 {
+			t.Errorf("expected connection to be reused, saw %d AUTH commands", n)
+		}
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		_, addr := startFakeRedis(t, "secret")
+
+		c, err := NewRedis("redis://:wrong@" + addr)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+
+		if _, _, err := c.Get(ctx, "k"); !errors.Is(err, ErrRedisReply) {
+			t.Errorf("expected error %v, got: %v", ErrRedisReply, err)
+		}
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		_, addr := startFakeRedis(t, "")
+
+		c, err := NewRedis("redis://" + addr)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if err := c.Set(ctx, "k", []byte("v"), time.Second); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+
+		c.Close()
+		if _, _, err := c.Get(ctx, "k"); !errors.Is(err, ErrRedisClosed) {
+			t.Errorf("expected error %v, got: %v", ErrRedisClosed, err)
+		}
+		if len(c.idle) != 0 {
+			t.Errorf("expected no idle connections after close, got %d", len(c.idle))
+		}
+	})
+
+	t.Run("error inside array", func(t *testing.T) {
+		r := bufio.NewReader(strings.NewReader("*3\r\n+OK\r\n-ERR first\r\n$1\r\nx\r\n+NEXT\r\n"))
+
+		if _, err := readReply(r); !errors.Is(err, ErrRedisReply) {
+			t.Fatalf("expected error %v, got: %v", ErrRedisReply, err)
+		}
+		if next, err := readReply(r); err != nil || next != "NEXT" {
+			t.Errorf("expected the array to be drained, got %v err=%v", next, err)
+		}
+	})
+
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{name: "wrong scheme", url: "http://localhost:6379"},
+		{name: "bad db", url: "redis://localhost:6379/x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewRedis(tt.url); !errors.Is(err, ErrRedisURL) {
+				t.Errorf("expected error %v, got: %v", ErrRedisURL, err)
+			}
+		})
+	}
+}
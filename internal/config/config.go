@@ -3,63 +3,638 @@ package config
 import (
 	"errors"
 	"fmt"
+	"maps"
+	"net/url"
 	"os"
+	"path"
+	"reflect"
+	"regexp"
 	"slices"
 	"strconv"
-
-	"gopkg.in/yaml.v3"
+	"strings"
+	"time"
 )
 
 var (
-	ErrReadConfig   = errors.New("unable to read config file")
-	ErrParseYAML    = errors.New("error parsing yaml")
-	ErrInvalidPort  = errors.New("invalid port value")
-	ErrInvalidDebug = errors.New("invalid debug value")
+	ErrReadConfig             = errors.New("unable to read config file")
+	ErrParseYAML              = errors.New("error parsing yaml")
+	ErrInvalidPort            = errors.New("invalid port value")
+	ErrInvalidDebug           = errors.New("invalid debug value")
+	ErrInvalidShutdownTimeout = errors.New("invalid shutdown_timeout value")
+	ErrInvalidStrict          = errors.New("invalid strict_config value")
+	ErrInvalidFeature         = errors.New("invalid feature flag value")
+	ErrUnknownField           = errors.New("unknown config field")
 
 	ErrValidationFailed   = errors.New("config validation failed")
 	ErrMissingDatabaseURL = errors.New("database_url is required")
 	ErrInvalidPortRange   = errors.New("port must be between 1 and 65535")
 	ErrMissingAPIKey      = errors.New("api key is missing")
 	ErrInvalidEnvironment = errors.New("environment must be one of: development, staging, production")
+	ErrInvalidRateLimit   = errors.New("rate limit must be non-negative with per set when requests is set")
+	ErrInvalidCacheURL    = errors.New("cache redis_url must be a redis:// or rediss:// URL")
+	ErrInvalidCacheTTL    = errors.New("cache ttl must be positive")
+	ErrInvalidShutdown    = errors.New("shutdown_timeout must be positive")
+	ErrInvalidChannel     = errors.New("invalid notification channel")
+	ErrInvalidRetry       = errors.New("notification retry values must not be negative")
+	ErrMissingSigningKey  = errors.New("signing.key_file is required when signing is enabled")
+	ErrInvalidDedupe      = errors.New("dedupe ttl and capacity must not be negative and false_positive_rate must be between 0 and 1")
+	ErrInvalidProvider    = errors.New("provider must be one of: simulator")
+	ErrSimulatorOnlyDev   = errors.New("simulator provider is only available in development")
+	ErrInvalidSimulator   = errors.New("simulator tick_interval and volatility must not be negative")
+	ErrInvalidFailover    = errors.New("invalid provider failover settings")
+	ErrInvalidShadow      = errors.New("invalid shadow provider settings")
+	ErrInvalidRoute       = errors.New("invalid provider route")
+	ErrInvalidListener    = errors.New("invalid server listener")
+	ErrInvalidDrain       = errors.New("server.drain_period must not be negative")
+	ErrInvalidLimits      = errors.New("server.limits values must not be negative")
+	ErrInvalidFeatureName = errors.New("feature names must be lowercase letters, digits and underscores")
+	ErrInvalidHealth      = errors.New("invalid notification health settings")
+	ErrInvalidQuietHours  = errors.New("invalid quiet hours")
+	ErrInvalidRouting     = errors.New("invalid notification routing")
+	ErrInvalidInbox       = errors.New("invalid webhook inbox provider")
+	ErrInvalidDigest      = errors.New("invalid notification digest")
 )
 
 var validEnvironments = []string{"development", "staging", "production"}
 
+// featureEnvPrefix prefixes env vars overriding single feature flags, e.g.
+// MARKETFLASH_FEATURE_OPTIONS_DATA=true sets features.options_data.
+const featureEnvPrefix = "MARKETFLASH_FEATURE_"
+
+var featureNamePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
 type config struct {
-	DatabaseURL string `yaml:"database_url"`
+	DatabaseURL string `yaml:"database_url" redact:"url"`
 	Port        int    `yaml:"port"`
 	Environment string `yaml:"environment"`
-	APIKey      string `yaml:"api_key"`
+	APIKey      string `yaml:"api_key" redact:"true"`
 	Debug       bool   `yaml:"debug"`
+
+	// ShutdownTimeout bounds how long components get to drain on shutdown.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+
+	Server ServerConfig `yaml:"server"`
+
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
+	Cache     CacheConfig     `yaml:"cache"`
+
+	Notifications NotificationsConfig `yaml:"notifications"`
+	Signing       SigningConfig       `yaml:"signing"`
+
+	// Inbox accepts callbacks pushed by upstream providers, keyed by the
+	// provider name in the callback URL.
+	Inbox map[string]InboxConfig `yaml:"inbox"`
+
+	// Provider selects the primary market data source; empty runs without
+	// one.
+	Provider  string          `yaml:"provider"`
+	Failover  FailoverConfig  `yaml:"failover"`
+	Routing   []RouteConfig   `yaml:"routing"`
+	Shadow    ShadowConfig    `yaml:"shadow"`
+	Simulator SimulatorConfig `yaml:"simulator"`
+
+	// Features turns subsystems on or off so incomplete work can ship dark.
+	// Flags missing from the map are off.
+	Features map[string]bool `yaml:"features"`
+}
+
+// ServerConfig configures the API server. Without listeners it accepts TCP
+// connections on port.
+//
+// DrainPeriod is how long the app keeps serving after SIGTERM with its
+// readiness probe failing, so load balancers move traffic away before
+// shutdown_timeout starts counting. It should exceed the balancer's probe
+// interval times its failure threshold.
+type ServerConfig struct {
+	Listeners   []ListenerConfig `yaml:"listeners"`
+	DrainPeriod time.Duration    `yaml:"drain_period"`
+	Limits      LimitsConfig     `yaml:"limits"`
+}
+
+// LimitsConfig bounds request bodies. Zero values use the defaults: 1 MiB
+// bodies, 10 MiB once decompressed, a 100:1 compression ratio and JSON
+// nested 32 levels deep.
+type LimitsConfig struct {
+	MaxBodyBytes          int64   `yaml:"max_body_bytes"`
+	MaxDecompressedBytes  int64   `yaml:"max_decompressed_bytes"`
+	MaxDecompressionRatio float64 `yaml:"max_decompression_ratio"`
+	MaxJSONDepth          int     `yaml:"max_json_depth"`
+}
+
+var validListenerTypes = []string{"tcp", "unix", "systemd"}
+
+// ListenerConfig is a socket the API server accepts connections on. Type is
+// one of:
+//
+//	tcp:      Address is host:port
+//	unix:     Address is a socket path; a stale socket there is replaced
+//	systemd:  a socket passed by systemd socket activation; Address matches
+//	          its FileDescriptorName, and empty takes every passed socket
+//
+// ReusePort sets SO_REUSEPORT on a tcp listener (Linux only), so a new
+// binary can bind the same port and start serving before the old one is
+// sent SIGTERM and drains.
+type ListenerConfig struct {
+	Type      string `yaml:"type"`
+	Address   string `yaml:"address"`
+	ReusePort bool   `yaml:"reuse_port"`
+}
+
+func (l ListenerConfig) validate() error {
+	if !slices.Contains(validListenerTypes, l.Type) {
+		return fmt.Errorf("%w: type must be one of: %s, got %q", ErrInvalidListener, strings.Join(validListenerTypes, ", "), l.Type)
+	}
+	if l.Type != "systemd" && l.Address == "" {
+		return fmt.Errorf("%w: %s listener needs an address", ErrInvalidListener, l.Type)
+	}
+	if l.ReusePort && l.Type != "tcp" {
+		return fmt.Errorf("%w: reuse_port only applies to tcp listeners", ErrInvalidListener)
+	}
+	return nil
+}
+
+// SigningConfig enables detached Ed25519 signatures on REST responses and
+// archive files. KeyFile holds a PKCS#8 PEM private key, as produced by
+// `openssl genpkey -algorithm ed25519`.
+type SigningConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	KeyFile string `yaml:"key_file"`
+}
+
+// InboxConfig verifies callbacks from one provider. Each callback carries a
+// hex HMAC-SHA256 of its body under Secret in SignatureHeader, optionally
+// prefixed "sha256=". With TimestampHeader set, the signature covers
+// "<timestamp>.<body>" instead and callbacks whose Unix timestamp is more
+// than Tolerance (default 5m) away from now are rejected as replays.
+type InboxConfig struct {
+	Secret          string        `yaml:"secret" redact:"true"`
+	SignatureHeader string        `yaml:"signature_header"`
+	TimestampHeader string        `yaml:"timestamp_header"`
+	Tolerance       time.Duration `yaml:"tolerance"`
+}
+
+func (c InboxConfig) validate() error {
+	switch {
+	case c.Secret == "":
+		return fmt.Errorf("%w: secret is required", ErrInvalidInbox)
+	case c.SignatureHeader == "":
+		return fmt.Errorf("%w: signature_header is required", ErrInvalidInbox)
+	case c.Tolerance < 0:
+		return fmt.Errorf("%w: tolerance must not be negative, got %s", ErrInvalidInbox, c.Tolerance)
+	}
+	return nil
+}
+
+var validProviders = []string{"simulator"}
+
+// FailoverConfig lists fallback providers, in priority order, that take over
+// when the primary disconnects or sends no ticks for StaleAfter (default
+// 30s). The highest-priority healthy provider is always preferred, so the
+// primary is failed back to once it recovers. A non-zero ReconcileTolerance
+// flags quotes that differ between providers by more than that fraction,
+// e.g. 0.005 for 0.5%.
+type FailoverConfig struct {
+	Providers          []string      `yaml:"providers"`
+	StaleAfter         time.Duration `yaml:"stale_after"`
+	ReconcileTolerance float64       `yaml:"reconcile_tolerance"`
+}
+
+// RouteConfig sends symbols matching any of Symbols, either exactly or as a
+// path.Match glob such as "*-USD", to Providers in failover order. Routes are
+// tried in order and symbols matching none use provider and
+// failover.providers. Failover timing and reconciliation settings apply to
+// every route.
+type RouteConfig struct {
+	Symbols   []string `yaml:"symbols"`
+	Providers []string `yaml:"providers"`
+}
+
+func (r RouteConfig) validate() error {
+	if len(r.Symbols) == 0 || len(r.Providers) == 0 {
+		return fmt.Errorf("%w: symbols and providers are required", ErrInvalidRoute)
+	}
+
+	for _, pattern := range r.Symbols {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%w: bad symbol pattern %q", ErrInvalidRoute, pattern)
+		}
+	}
+
+	for _, name := range r.Providers {
+		if !slices.Contains(validProviders, name) {
+			return fmt.Errorf("%w: got %q", ErrInvalidProvider, name)
+		}
+	}
+
+	return nil
+}
+
+// ShadowConfig runs Provider next to the primary purely for comparison, to
+// evaluate a candidate feed; its ticks never reach the pipeline. Quotes that
+// differ from the primary's by more than Tolerance (default 0.001, i.e. 0.1%)
+// count as divergences.
+type ShadowConfig struct {
+	Provider  string  `yaml:"provider"`
+	Tolerance float64 `yaml:"tolerance"`
+}
+
+// SimulatorConfig drives the development market data simulator, which emits
+// random-walk ticks for Symbols every TickInterval (default 1s). Volatility is
+// the standard deviation of each tick's log return (default 0.001); a
+// non-zero Seed makes the streams reproducible for integration tests.
+type SimulatorConfig struct {
+	Symbols      []string      `yaml:"symbols"`
+	TickInterval time.Duration `yaml:"tick_interval"`
+	Volatility   float64       `yaml:"volatility"`
+	Seed         int64         `yaml:"seed"`
+}
+
+var validChannelTypes = []string{"webhook", "slack", "email", "telegram"}
+
+// NotificationsConfig configures alert delivery channels, keyed by channel
+// name, and how failed deliveries are retried.
+type NotificationsConfig struct {
+	Retry    RetryConfig              `yaml:"retry"`
+	Dedupe   DedupeConfig             `yaml:"dedupe"`
+	Health   HealthConfig             `yaml:"health"`
+	Channels map[string]ChannelConfig `yaml:"channels"`
+
+	// Routing maps a severity (info, warning or critical) to the channels
+	// its alerts go to.
+	Routing map[string]SeverityRoute `yaml:"routing"`
+}
+
+// SeverityRoute sends alerts of one severity to every channel in Channels.
+// Repeats of the same alert within RepeatInterval are suppressed; zero sends
+// every repeat.
+type SeverityRoute struct {
+	Channels       []string      `yaml:"channels"`
+	RepeatInterval time.Duration `yaml:"repeat_interval"`
+}
+
+// HealthConfig watches each channel's delivery failure rate over a sliding
+// Window (default 15m). Once at least MinDeliveries (default 5) have finished
+// in the window and the failure rate reaches FailureThreshold (default 0.5),
+// an alert is sent to AlertChannel. Without an AlertChannel, rates are only
+// tracked for metrics.
+type HealthConfig struct {
+	Window           time.Duration `yaml:"window"`
+	FailureThreshold float64       `yaml:"failure_threshold"`
+	MinDeliveries    int           `yaml:"min_deliveries"`
+	AlertChannel     string        `yaml:"alert_channel"`
+}
+
+// DedupeConfig suppresses repeat deliveries of the same message to the same
+// channel for at least TTL. A zero TTL disables dedupe; an empty Path keeps
+// it in memory only, losing it on restart. Capacity and FalsePositiveRate
+// size the underlying Bloom filter and default to 100000 and 1e-6.
+type DedupeConfig struct {
+	TTL               time.Duration `yaml:"ttl"`
+	Path              string        `yaml:"path"`
+	Capacity          int           `yaml:"capacity"`
+	FalsePositiveRate float64       `yaml:"false_positive_rate"`
+}
+
+// RetryConfig controls delivery retries with exponential backoff. Zero values
+// fall back to the dispatcher defaults.
+type RetryConfig struct {
+	MaxAttempts    int           `yaml:"max_attempts"`
+	InitialBackoff time.Duration `yaml:"initial_backoff"`
+	MaxBackoff     time.Duration `yaml:"max_backoff"`
+}
+
+// ChannelConfig describes one delivery channel. Which fields apply depends on
+// Type:
+//
+//	webhook:  url, secret (HMAC-SHA256 signing key)
+//	slack:    url (incoming webhook)
+//	email:    smtp_host, smtp_port, username, password, from, to
+//	telegram: bot_token, chat_id
+type ChannelConfig struct {
+	Type      string     `yaml:"type"`
+	RateLimit RateConfig `yaml:"rate_limit"`
+
+	URL    string `yaml:"url" redact:"true"`
+	Secret string `yaml:"secret" redact:"true"`
+
+	SMTPHost string   `yaml:"smtp_host"`
+	SMTPPort int      `yaml:"smtp_port"`
+	Username string   `yaml:"username"`
+	Password string   `yaml:"password" redact:"true"`
+	From     string   `yaml:"from"`
+	To       []string `yaml:"to"`
+
+	BotToken string `yaml:"bot_token" redact:"true"`
+	ChatID   string `yaml:"chat_id"`
+
+	// Owners are the IDs of the API keys that may send test messages to the
+	// channel; other keys cannot target it.
+	Owners []string `yaml:"owners"`
+
+	// QuietHours are the recipient's off-hours for this channel.
+	QuietHours QuietHoursConfig `yaml:"quiet_hours"`
+	Digest     DigestConfig     `yaml:"digest"`
+}
+
+// DigestConfig batches low-severity messages on a channel: messages whose
+// severity is in Severities (default info) are held and sent as one digest
+// message every Interval. A zero Interval sends every message on its own.
+type DigestConfig struct {
+	Interval   time.Duration `yaml:"interval"`
+	Severities []string      `yaml:"severities"`
+}
+
+// Enabled reports whether messages are batched into digests.
+func (d DigestConfig) Enabled() bool {
+	return d.Interval > 0
+}
+
+func (d DigestConfig) validate() error {
+	if d.Interval < 0 {
+		return fmt.Errorf("%w: interval must not be negative, got %s", ErrInvalidDigest, d.Interval)
+	}
+	for _, severity := range d.Severities {
+		if !slices.Contains(validSeverities, severity) {
+			return fmt.Errorf("%w: severity must be one of: %s, got %q", ErrInvalidDigest, strings.Join(validSeverities, ", "), severity)
+		}
+	}
+	return nil
+}
+
+var (
+	validSeverities      = []string{"info", "warning", "critical"}
+	validQuietHourPolicy = []string{"queue", "drop", "deliver"}
+)
+
+// QuietHoursConfig holds a recipient's off-hours, from Start to End as
+// "15:04" clock times in Timezone (an IANA name, default UTC). An End before
+// Start spans midnight. During quiet hours each message is handled by the
+// Policy for its severity: "queue" holds it until quiet hours end, up to 1000
+// messages per channel beyond which they are dead-lettered, "drop" discards
+// it and "deliver" sends it anyway. Severities missing from Policy are
+// queued, except critical, which is delivered.
+type QuietHoursConfig struct {
+	Start    string            `yaml:"start"`
+	End      string            `yaml:"end"`
+	Timezone string            `yaml:"timezone"`
+	Policy   map[string]string `yaml:"policy"`
+}
+
+// Enabled reports whether quiet hours are configured.
+func (q QuietHoursConfig) Enabled() bool {
+	return q.Start != "" || q.End != ""
+}
+
+// Bounds returns Start and End as offsets from midnight, and the location
+// they apply in.
+func (q QuietHoursConfig) Bounds() (start, end time.Duration, loc *time.Location, err error) {
+	clock := func(field, v string) (time.Duration, error) {
+		t, err := time.Parse("15:04", v)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %s must be a 15:04 clock time, got %q", ErrInvalidQuietHours, field, v)
+		}
+		return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+	}
+
+	if start, err = clock("start", q.Start); err != nil {
+		return 0, 0, nil, err
+	}
+	if end, err = clock("end", q.End); err != nil {
+		return 0, 0, nil, err
+	}
+	if start == end {
+		return 0, 0, nil, fmt.Errorf("%w: start and end must differ", ErrInvalidQuietHours)
+	}
+
+	loc, err = time.LoadLocation(q.Timezone)
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("%w: unknown timezone %q", ErrInvalidQuietHours, q.Timezone)
+	}
+
+	return start, end, loc, nil
+}
+
+func (q QuietHoursConfig) validate() error {
+	if !q.Enabled() {
+		return nil
+	}
+	if _, _, _, err := q.Bounds(); err != nil {
+		return err
+	}
+
+	for _, severity := range slices.Sorted(maps.Keys(q.Policy)) {
+		if !slices.Contains(validSeverities, severity) {
+			return fmt.Errorf("%w: severity must be one of: %s, got %q", ErrInvalidQuietHours, strings.Join(validSeverities, ", "), severity)
+		}
+		if policy := q.Policy[severity]; !slices.Contains(validQuietHourPolicy, policy) {
+			return fmt.Errorf("%w: policy must be one of: %s, got %q", ErrInvalidQuietHours, strings.Join(validQuietHourPolicy, ", "), policy)
+		}
+	}
+
+	return nil
+}
+
+func (c ChannelConfig) validate() error {
+	var missing []string
+
+	switch c.Type {
+	case "webhook", "slack":
+		if c.URL == "" {
+			missing = append(missing, "url")
+		}
+	case "email":
+		if c.SMTPHost == "" {
+			missing = append(missing, "smtp_host")
+		}
+		if c.From == "" {
+			missing = append(missing, "from")
+		}
+		if len(c.To) == 0 {
+			missing = append(missing, "to")
+		}
+	case "telegram":
+		if c.BotToken == "" {
+			missing = append(missing, "bot_token")
+		}
+		if c.ChatID == "" {
+			missing = append(missing, "chat_id")
+		}
+	default:
+		return fmt.Errorf("%w: type must be one of: %s, got %q", ErrInvalidChannel, strings.Join(validChannelTypes, ", "), c.Type)
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s channel requires %s", ErrInvalidChannel, c.Type, strings.Join(missing, ", "))
+	}
+
+	if c.SMTPPort < 0 || c.SMTPPort > 65535 {
+		return fmt.Errorf("%w: smtp_port must be between 0 and 65535, got %d", ErrInvalidChannel, c.SMTPPort)
+	}
+
+	if err := c.QuietHours.validate(); err != nil {
+		return err
+	}
+
+	if err := c.Digest.validate(); err != nil {
+		return err
+	}
+
+	return c.RateLimit.validate()
+}
+
+// CacheConfig configures the read cache in front of quote and candle lookups.
+// Without a RedisURL an in-process cache is used instead.
+type CacheConfig struct {
+	RedisURL  string        `yaml:"redis_url" redact:"url"`
+	QuoteTTL  time.Duration `yaml:"quote_ttl"`
+	CandleTTL time.Duration `yaml:"candle_ttl"`
+}
+
+func (c CacheConfig) validate() []error {
+	var errs []error
+
+	if c.RedisURL != "" {
+		u, err := url.Parse(c.RedisURL)
+		if err != nil || (u.Scheme != "redis" && u.Scheme != "rediss") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("%w: got %q", ErrInvalidCacheURL, c.RedisURL))
+		}
+	}
+
+	if c.QuoteTTL <= 0 {
+		errs = append(errs, fmt.Errorf("%w: quote_ttl got %s", ErrInvalidCacheTTL, c.QuoteTTL))
+	}
+
+	if c.CandleTTL <= 0 {
+		errs = append(errs, fmt.Errorf("%w: candle_ttl got %s", ErrInvalidCacheTTL, c.CandleTTL))
+	}
+
+	return errs
+}
+
+// RateLimitConfig holds the inbound API limit, applied per authenticated API
+// key or client IP, and outbound limits keyed by upstream provider name.
+type RateLimitConfig struct {
+	Inbound   RateConfig            `yaml:"inbound"`
+	Providers map[string]RateConfig `yaml:"providers"`
 }
 
+// RateConfig allows Requests per Per, with bursts of up to Burst requests.
+// A zero Requests disables the limit.
+type RateConfig struct {
+	Requests int           `yaml:"requests"`
+	Per      time.Duration `yaml:"per"`
+	Burst    int           `yaml:"burst"`
+}
+
+// Enabled reports whether the limit is configured.
+func (r RateConfig) Enabled() bool {
+	return r.Requests > 0
+}
+
+// Rate returns the limit in requests per second.
+func (r RateConfig) Rate() float64 {
+	if !r.Enabled() || r.Per <= 0 {
+		return 0
+	}
+	return float64(r.Requests) / r.Per.Seconds()
+}
+
+func (r RateConfig) validate() error {
+	if r.Requests < 0 || r.Per < 0 || r.Burst < 0 || (r.Requests > 0 && r.Per == 0) {
+		return fmt.Errorf("%w: got %d per %s, burst %d", ErrInvalidRateLimit, r.Requests, r.Per, r.Burst)
+	}
+	return nil
+}
+
+// LoadConfig loads the config file at cfgPath together with the overlay for
+// the configured environment, if one exists. See LoadConfigWithOverlay.
 func LoadConfig(cfgPath string) (config, error) {
+	return LoadConfigWithOverlay(cfgPath, "")
+}
+
+// LoadConfigWithOverlay builds the config from, in increasing precedence:
+//
+//  1. built-in defaults
+//  2. the base file at cfgPath
+//  3. the overlay file
+//  4. environment variables
+//
+// The overlay is overlayPath when given (e.g. from --config-overlay), in which
+// case it must exist. Otherwise it is derived from cfgPath and the environment
+// (config.yaml -> config.production.yaml) and skipped when missing. Overlay
+// values are deep-merged into the base file, so an overlay only needs the
+// keys it changes, including single keys inside nested sections and map
+// entries such as notifications.channels.<name>. Lists are replaced whole.
+//
+// File values of the form enc:aes256gcm:<base64> are decrypted with the key
+// in CONFIG_KEY, or in the file named by CONFIG_KEY_FILE, so config files can
+// be committed without plaintext secrets. See EncryptValue.
+func LoadConfigWithOverlay(cfgPath, overlayPath string) (config, error) {
 	cfg := config{
-		Port:        8080,
-		Environment: "development",
-		Debug:       false,
+		Port:            8080,
+		Environment:     "development",
+		Debug:           false,
+		ShutdownTimeout: 30 * time.Second,
+		Cache: CacheConfig{
+			QuoteTTL:  5 * time.Second,
+			CandleTTL: time.Minute,
+		},
 	}
 
-	if cfgPath != "" {
-		data, err := os.ReadFile(cfgPath) // data []byte
+	// origins records which file or env var last set each key, so errors
+	// can point at the source of a bad value.
+	origins := make(map[string]string)
+	var files []configFile
+	dec := &decrypter{}
 
-		if err != nil && !os.IsNotExist(err) {
-			return config{}, fmt.Errorf("%w: %s", ErrReadConfig, err)
+	if cfgPath != "" {
+		file, err := readConfigFile(cfgPath, false)
+		if err != nil {
+			return config{}, err
 		}
+		if file.data != nil {
+			if err := file.parse(dec); err != nil {
+				return config{}, err
+			}
+			if err := file.decodeInto(&cfg, nil, origins); err != nil {
+				return config{}, err
+			}
+			files = append(files, file)
+		}
+	}
 
-		if err == nil && len(data) == 0 {
-			return config{}, fmt.Errorf("%w: config file is empty", ErrReadConfig)
+	overlayRequired := overlayPath != ""
+	if !overlayRequired && cfgPath != "" {
+		env := cfg.Environment
+		if e, ok := os.LookupEnv("ENVIRONMENT"); ok {
+			env = e
 		}
+		overlayPath = overlayPathFor(cfgPath, env)
+	}
 
-		if err == nil && len(data) > 0 {
-			if err := yaml.Unmarshal(data, &cfg); err != nil {
-				return config{}, fmt.Errorf("%w: %s", ErrParseYAML, err)
+	if overlayPath != "" {
+		file, err := readConfigFile(overlayPath, overlayRequired)
+		if err != nil {
+			return config{}, err
+		}
+		if file.data != nil {
+			var base *configFile
+			if len(files) > 0 {
+				base = &files[0]
+			}
+			if err := file.parse(dec); err != nil {
+				return config{}, err
 			}
+			if err := file.decodeInto(&cfg, base, origins); err != nil {
+				return config{}, err
+			}
+			files = append(files, file)
 		}
 	}
 
 	if dbURL, ok := os.LookupEnv("DATABASE_URL"); ok {
 		cfg.DatabaseURL = dbURL
+		origins["database_url"] = "env DATABASE_URL"
 	}
 
 	if portStr, ok := os.LookupEnv("PORT"); ok {
@@ -68,14 +643,32 @@ func LoadConfig(cfgPath string) (config, error) {
 			return config{}, fmt.Errorf("%w: got %q", ErrInvalidPort, portStr)
 		}
 		cfg.Port = int(port)
+		origins["port"] = "env PORT"
 	}
 
 	if apiKey, ok := os.LookupEnv("API_KEY"); ok {
 		cfg.APIKey = apiKey
+		origins["api_key"] = "env API_KEY"
+	}
+
+	if redisURL, ok := os.LookupEnv("REDIS_URL"); ok {
+		cfg.Cache.RedisURL = redisURL
+		origins["cache.redis_url"] = "env REDIS_URL"
+	}
+
+	if keyFile, ok := os.LookupEnv("SIGNING_KEY_FILE"); ok {
+		cfg.Signing.KeyFile = keyFile
+		origins["signing.key_file"] = "env SIGNING_KEY_FILE"
+	}
+
+	if provider, ok := os.LookupEnv("PROVIDER"); ok {
+		cfg.Provider = provider
+		origins["provider"] = "env PROVIDER"
 	}
 
 	if env, ok := os.LookupEnv("ENVIRONMENT"); ok {
 		cfg.Environment = env
+		origins["environment"] = "env ENVIRONMENT"
 	}
 
 	if debugStr, ok := os.LookupEnv("DEBUG"); ok {
@@ -85,10 +678,68 @@ func LoadConfig(cfgPath string) (config, error) {
 			return config{}, fmt.Errorf("%w: got %q", ErrInvalidDebug, debugStr)
 		}
 		cfg.Debug = debug
+		origins["debug"] = "env DEBUG"
+	}
+
+	for _, kv := range os.Environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		name, ok := strings.CutPrefix(key, featureEnvPrefix)
+		if !ok {
+			continue
+		}
+
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return config{}, fmt.Errorf("%w: %s: got %q", ErrInvalidFeature, key, value)
+		}
+		name = strings.ToLower(name)
+		if cfg.Features == nil {
+			cfg.Features = make(map[string]bool)
+		}
+		cfg.Features[name] = enabled
+		origins["features."+name] = "env " + key
+	}
+
+	if timeoutStr, ok := os.LookupEnv("SHUTDOWN_TIMEOUT"); ok {
+		timeout, err := time.ParseDuration(timeoutStr)
+		if err != nil {
+			return config{}, fmt.Errorf("%w: got %q", ErrInvalidShutdownTimeout, timeoutStr)
+		}
+		cfg.ShutdownTimeout = timeout
+		origins["shutdown_timeout"] = "env SHUTDOWN_TIMEOUT"
+	}
+
+	// Strict decoding is on by default in production so a typo such as
+	// `databse_url` fails at startup instead of surfacing later as a
+	// missing-field validation error. STRICT_CONFIG overrides the default.
+	strict := cfg.Environment == "production"
+
+	if strictStr, ok := os.LookupEnv("STRICT_CONFIG"); ok {
+		v, err := strconv.ParseBool(strictStr)
+		if err != nil {
+			return config{}, fmt.Errorf("%w: got %q", ErrInvalidStrict, strictStr)
+		}
+		strict = v
+	}
+
+	if strict {
+		var unknown []string
+		for _, file := range files {
+			found, err := unknownFields(file.data, reflect.TypeFor[config]())
+			if err != nil {
+				return config{}, fmt.Errorf("%w: %s: %s", ErrParseYAML, file.path, err)
+			}
+			for _, f := range found {
+				unknown = append(unknown, file.path+": "+f)
+			}
+		}
+		if len(unknown) > 0 {
+			return config{}, fmt.Errorf("%w: %s", ErrUnknownField, strings.Join(unknown, ", "))
+		}
 	}
 
 	if err := cfg.Validate(); err != nil {
-		return config{}, fmt.Errorf("%w: %s", ErrValidationFailed, err.Error())
+		return config{}, fmt.Errorf("%w: %s%s", ErrValidationFailed, err.Error(), describeOrigins(err, origins))
 	}
 
 	return cfg, nil
@@ -113,6 +764,148 @@ func (c config) Validate() error {
 		errs = append(errs, fmt.Errorf("%w: got %q", ErrInvalidEnvironment, c.Environment))
 	}
 
+	if c.ShutdownTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("%w: got %s", ErrInvalidShutdown, c.ShutdownTimeout))
+	}
+
+	if c.Server.DrainPeriod < 0 {
+		errs = append(errs, fmt.Errorf("%w: got %s", ErrInvalidDrain, c.Server.DrainPeriod))
+	}
+
+	if l := c.Server.Limits; l.MaxBodyBytes < 0 || l.MaxDecompressedBytes < 0 || l.MaxDecompressionRatio < 0 || l.MaxJSONDepth < 0 {
+		errs = append(errs, ErrInvalidLimits)
+	}
+
+	for i, l := range c.Server.Listeners {
+		if err := l.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("server.listeners[%d]: %w", i, err))
+		}
+	}
+
+	if err := c.RateLimit.Inbound.validate(); err != nil {
+		errs = append(errs, fmt.Errorf("rate_limit.inbound: %w", err))
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(c.RateLimit.Providers)) {
+		if err := c.RateLimit.Providers[name].validate(); err != nil {
+			errs = append(errs, fmt.Errorf("rate_limit.providers.%s: %w", name, err))
+		}
+	}
+
+	errs = append(errs, c.Cache.validate()...)
+
+	retry := c.Notifications.Retry
+	if retry.MaxAttempts < 0 || retry.InitialBackoff < 0 || retry.MaxBackoff < 0 {
+		errs = append(errs, fmt.Errorf("%w: got %+v", ErrInvalidRetry, retry))
+	}
+
+	dedupe := c.Notifications.Dedupe
+	if dedupe.TTL < 0 || dedupe.Capacity < 0 || dedupe.FalsePositiveRate < 0 || dedupe.FalsePositiveRate >= 1 {
+		errs = append(errs, fmt.Errorf("%w: got %+v", ErrInvalidDedupe, dedupe))
+	}
+
+	health := c.Notifications.Health
+	if health.Window < 0 || health.MinDeliveries < 0 || health.FailureThreshold < 0 || health.FailureThreshold > 1 {
+		errs = append(errs, fmt.Errorf("%w: window and min_deliveries must not be negative and failure_threshold must be between 0 and 1, got %+v", ErrInvalidHealth, health))
+	}
+	if _, ok := c.Notifications.Channels[health.AlertChannel]; health.AlertChannel != "" && !ok {
+		errs = append(errs, fmt.Errorf("%w: alert_channel %q is not a configured channel", ErrInvalidHealth, health.AlertChannel))
+	}
+
+	for _, severity := range slices.Sorted(maps.Keys(c.Notifications.Routing)) {
+		route := c.Notifications.Routing[severity]
+		if !slices.Contains(validSeverities, severity) {
+			errs = append(errs, fmt.Errorf("%w: severity must be one of: %s, got %q", ErrInvalidRouting, strings.Join(validSeverities, ", "), severity))
+		}
+		if len(route.Channels) == 0 || route.RepeatInterval < 0 {
+			errs = append(errs, fmt.Errorf("%w: %s needs channels and a non-negative repeat_interval", ErrInvalidRouting, severity))
+		}
+		for _, name := range route.Channels {
+			if _, ok := c.Notifications.Channels[name]; !ok {
+				errs = append(errs, fmt.Errorf("%w: %s channel %q is not a configured channel", ErrInvalidRouting, severity, name))
+			}
+		}
+	}
+
+	if c.Signing.Enabled && c.Signing.KeyFile == "" {
+		errs = append(errs, ErrMissingSigningKey)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(c.Notifications.Channels)) {
+		if err := c.Notifications.Channels[name].validate(); err != nil {
+			errs = append(errs, fmt.Errorf("notifications.channels.%s: %w", name, err))
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(c.Inbox)) {
+		if err := c.Inbox[name].validate(); err != nil {
+			errs = append(errs, fmt.Errorf("inbox.%s: %w", name, err))
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(c.Features)) {
+		if !featureNamePattern.MatchString(name) {
+			errs = append(errs, fmt.Errorf("%w: got %q", ErrInvalidFeatureName, name))
+		}
+	}
+
+	if c.Provider != "" && !slices.Contains(validProviders, c.Provider) {
+		errs = append(errs, keyed("provider", fmt.Errorf("%w: got %q", ErrInvalidProvider, c.Provider)))
+	}
+
+	for _, name := range c.Failover.Providers {
+		if !slices.Contains(validProviders, name) {
+			errs = append(errs, keyed("failover.providers", fmt.Errorf("failover.providers: %w: got %q", ErrInvalidProvider, name)))
+		}
+	}
+
+	failover := c.Failover
+	switch {
+	case len(failover.Providers) > 0 && c.Provider == "":
+		errs = append(errs, fmt.Errorf("%w: providers need a primary provider", ErrInvalidFailover))
+	case failover.StaleAfter < 0 || failover.ReconcileTolerance < 0:
+		errs = append(errs, fmt.Errorf("%w: stale_after and reconcile_tolerance must not be negative, got %+v", ErrInvalidFailover, failover))
+	}
+
+	for i, route := range c.Routing {
+		if err := route.validate(); err != nil {
+			errs = append(errs, keyed("routing", fmt.Errorf("routing[%d]: %w", i, err)))
+		}
+	}
+	if len(c.Routing) > 0 && c.Provider == "" {
+		errs = append(errs, fmt.Errorf("%w: routing needs a primary provider for unrouted symbols", ErrInvalidRoute))
+	}
+
+	shadow := c.Shadow
+	switch {
+	case shadow.Provider != "" && !slices.Contains(validProviders, shadow.Provider):
+		errs = append(errs, keyed("shadow.provider", fmt.Errorf("shadow.provider: %w: got %q", ErrInvalidProvider, shadow.Provider)))
+	case shadow.Provider != "" && c.Provider == "":
+		errs = append(errs, fmt.Errorf("%w: a shadow provider needs a primary provider", ErrInvalidShadow))
+	case shadow.Tolerance < 0:
+		errs = append(errs, fmt.Errorf("%w: tolerance must not be negative, got %v", ErrInvalidShadow, shadow.Tolerance))
+	}
+
+	// simulatorKey is the first key selecting the simulator, if any.
+	var simulatorKey string
+	switch {
+	case c.Provider == "simulator":
+		simulatorKey = "provider"
+	case slices.Contains(c.Failover.Providers, "simulator"):
+		simulatorKey = "failover.providers"
+	case shadow.Provider == "simulator":
+		simulatorKey = "shadow.provider"
+	case slices.ContainsFunc(c.Routing, func(r RouteConfig) bool { return slices.Contains(r.Providers, "simulator") }):
+		simulatorKey = "routing"
+	}
+	if simulatorKey != "" && c.Environment != "development" {
+		errs = append(errs, keyed(simulatorKey, fmt.Errorf("%w: got environment %q", ErrSimulatorOnlyDev, c.Environment)))
+	}
+
+	if c.Simulator.TickInterval < 0 || c.Simulator.Volatility < 0 {
+		errs = append(errs, fmt.Errorf("%w: got %+v", ErrInvalidSimulator, c.Simulator))
+	}
+
 	if len(errs) > 0 {
 		return errors.Join(errs...)
 	}
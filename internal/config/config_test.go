@@ -3,10 +3,14 @@ package config
 import (
 	"errors"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
+var defaultCache = CacheConfig{QuoteTTL: 5 * time.Second, CandleTTL: time.Minute}
+
 func setEnv(t *testing.T, env map[string]string) {
 	t.Helper()
 	for k, v := range env {
@@ -57,13 +61,15 @@ api_key: test-key
 			t.Errorf("expected no error, got: %v", err)
 		}
 		want := config{
-			DatabaseURL: "postgres://localhost:5432/test",
-			Port:        8080,
-			Environment: "production",
-			Debug:       true,
-			APIKey:      "test-key",
+			DatabaseURL:     "postgres://localhost:5432/test",
+			Port:            8080,
+			Environment:     "production",
+			Debug:           true,
+			APIKey:          "test-key",
+			ShutdownTimeout: 30 * time.Second,
+			Cache:           defaultCache,
 		}
-		if cfg != want {
+		if !reflect.DeepEqual(cfg, want) {
 			t.Errorf("expected config %+v, got: %+v", want, cfg)
 		}
 	})
@@ -98,17 +104,46 @@ api_key: test-key
 			t.Errorf("expected no error, got: %v", err)
 		}
 		want := config{
-			DatabaseURL: "postgres://localhost:5432/test",
-			Port:        8080,
-			Environment: "production",
-			Debug:       true,
-			APIKey:      "test-key",
+			DatabaseURL:     "postgres://localhost:5432/test",
+			Port:            8080,
+			Environment:     "production",
+			Debug:           true,
+			APIKey:          "test-key",
+			ShutdownTimeout: 30 * time.Second,
+			Cache:           defaultCache,
 		}
-		if cfg != want {
+		if !reflect.DeepEqual(cfg, want) {
 			t.Errorf("expected config %+v, got: %+v", want, cfg)
 		}
 	})
 
+	t.Run("feature env overrides file", func(t *testing.T) {
+		os.Clearenv()
+
+		setEnv(t, map[string]string{
+			"MARKETFLASH_FEATURE_NEW_AGGREGATOR": "true",
+			"MARKETFLASH_FEATURE_OPTIONS_DATA":   "0",
+		})
+
+		path := createTempConfigFile(t, `
+database_url: postgres://localhost:5432/test
+api_key: test-key
+features:
+  options_data: true
+  new_aggregator: false
+  watchlists: true
+`)
+
+		cfg, err := LoadConfig(path)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		want := map[string]bool{"options_data": false, "new_aggregator": true, "watchlists": true}
+		if !reflect.DeepEqual(cfg.Features, want) {
+			t.Errorf("expected features %v, got: %v", want, cfg.Features)
+		}
+	})
+
 	t.Run("invalid yaml", func(t *testing.T) {
 		os.Clearenv()
 
@@ -176,17 +211,124 @@ api_key: test-key
 			t.Errorf("expected no error, got: %v", err)
 		}
 		want := config{
-			DatabaseURL: "postgres://localhost:5432/test",
-			Port:        8080,
-			Environment: "development",
-			Debug:       false,
-			APIKey:      "test-key",
+			DatabaseURL:     "postgres://localhost:5432/test",
+			Port:            8080,
+			Environment:     "development",
+			Debug:           false,
+			APIKey:          "test-key",
+			ShutdownTimeout: 30 * time.Second,
+			Cache:           defaultCache,
 		}
-		if cfg != want {
+		if !reflect.DeepEqual(cfg, want) {
 			t.Errorf("expected config %+v, got: %+v", want, cfg)
 		}
 	})
 
+	t.Run("rate limit section", func(t *testing.T) {
+		os.Clearenv()
+
+		configContent := `
+database_url: postgres://localhost:5432/test
+api_key: test-key
+rate_limit:
+  inbound:
+    requests: 100
+    per: 1m
+    burst: 20
+  providers:
+    alpha_vantage:
+      requests: 5
+      per: 1m
+`
+		path := createTempConfigFile(t, configContent)
+
+		cfg, err := LoadConfig(path)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		want := RateLimitConfig{
+			Inbound: RateConfig{Requests: 100, Per: time.Minute, Burst: 20},
+			Providers: map[string]RateConfig{
+				"alpha_vantage": {Requests: 5, Per: time.Minute},
+			},
+		}
+		if !reflect.DeepEqual(cfg.RateLimit, want) {
+			t.Errorf("expected rate limit %+v, got: %+v", want, cfg.RateLimit)
+		}
+		if got := cfg.RateLimit.Providers["alpha_vantage"].Rate(); got != 5.0/60 {
+			t.Errorf("expected rate %v, got %v", 5.0/60, got)
+		}
+	})
+
+	t.Run("notification channels", func(t *testing.T) {
+		os.Clearenv()
+
+		configContent := `
+database_url: postgres://localhost:5432/test
+api_key: test-key
+notifications:
+  retry:
+    max_attempts: 4
+  channels:
+    ops:
+      type: slack
+      url: https://hooks.slack.com/services/T/B/X
+      rate_limit:
+        requests: 1
+        per: 1s
+    oncall:
+      type: email
+      smtp_host: smtp.example.com
+      smtp_port: 587
+      from: marketflash@example.com
+      to: [oncall@example.com]
+`
+		path := createTempConfigFile(t, configContent)
+
+		cfg, err := LoadConfig(path)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		want := NotificationsConfig{
+			Retry: RetryConfig{MaxAttempts: 4},
+			Channels: map[string]ChannelConfig{
+				"ops": {
+					Type:      "slack",
+					URL:       "https://hooks.slack.com/services/T/B/X",
+					RateLimit: RateConfig{Requests: 1, Per: time.Second},
+				},
+				"oncall": {
+					Type:     "email",
+					SMTPHost: "smtp.example.com",
+					SMTPPort: 587,
+					From:     "marketflash@example.com",
+					To:       []string{"oncall@example.com"},
+				},
+			},
+		}
+		if !reflect.DeepEqual(cfg.Notifications, want) {
+			t.Errorf("expected notifications %+v, got: %+v", want, cfg.Notifications)
+		}
+	})
+
+	t.Run("redis url from env", func(t *testing.T) {
+		os.Clearenv()
+
+		setEnv(t, map[string]string{
+			"DATABASE_URL": "postgres://localhost:5432/test",
+			"API_KEY":      "test-key",
+			"REDIS_URL":    "redis://cache:6379/1",
+		})
+
+		cfg, err := LoadConfig("")
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if cfg.Cache.RedisURL != "redis://cache:6379/1" {
+			t.Errorf("expected redis url from env, got %q", cfg.Cache.RedisURL)
+		}
+	})
+
 	t.Run("file read failure", func(t *testing.T) {
 		os.Clearenv()
 
@@ -227,6 +369,24 @@ api_key: test-key
 			},
 			wantErr: ErrInvalidDebug,
 		},
+		{
+			name: "invalid shutdown timeout env",
+			env: map[string]string{
+				"SHUTDOWN_TIMEOUT": "soon",
+				"DATABASE_URL":     "postgres://localhost:5432/test",
+				"API_KEY":          "test-key",
+			},
+			wantErr: ErrInvalidShutdownTimeout,
+		},
+		{
+			name: "invalid feature env",
+			env: map[string]string{
+				"MARKETFLASH_FEATURE_OPTIONS_DATA": "maybe",
+				"DATABASE_URL":                     "postgres://localhost:5432/test",
+				"API_KEY":                          "test-key",
+			},
+			wantErr: ErrInvalidFeature,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -267,73 +427,361 @@ func TestValidate(t *testing.T) {
 		{
 			name: "valid config",
 			config: config{
-				DatabaseURL: "postgres://localhost:5432/test",
-				Port:        8080,
-				Environment: "production",
-				Debug:       true,
-				APIKey:      "test-key",
+				DatabaseURL:     "postgres://localhost:5432/test",
+				Port:            8080,
+				Environment:     "production",
+				Debug:           true,
+				APIKey:          "test-key",
+				ShutdownTimeout: 30 * time.Second,
+				Cache:           defaultCache,
 			},
 			wantErrs: nil,
 		},
 		{
 			name: "missing database_url",
 			config: config{
-				Port:        8080,
-				Environment: "production",
-				APIKey:      "test-key",
+				Port:            8080,
+				Environment:     "production",
+				APIKey:          "test-key",
+				ShutdownTimeout: 30 * time.Second,
+				Cache:           defaultCache,
 			},
 			wantErrs: []error{ErrMissingDatabaseURL},
 		},
 		{
 			name: "missing api_key",
 			config: config{
-				DatabaseURL: "postgres://localhost:5432/test",
-				Port:        8080,
-				Environment: "production",
+				DatabaseURL:     "postgres://localhost:5432/test",
+				Port:            8080,
+				Environment:     "production",
+				ShutdownTimeout: 30 * time.Second,
+				Cache:           defaultCache,
 			},
 			wantErrs: []error{ErrMissingAPIKey},
 		},
 		{
 			name: "invalid port",
 			config: config{
-				DatabaseURL: "postgres://localhost:5432/test",
-				Port:        0,
-				Environment: "production",
-				APIKey:      "test-key",
+				DatabaseURL:     "postgres://localhost:5432/test",
+				Port:            0,
+				Environment:     "production",
+				APIKey:          "test-key",
+				ShutdownTimeout: 30 * time.Second,
+				Cache:           defaultCache,
 			},
 			wantErrs: []error{ErrInvalidPortRange},
 		},
 		{
 			name: "invalid environment",
+			config: config{
+				DatabaseURL:     "postgres://localhost:5432/test",
+				Port:            8080,
+				Environment:     "invalid",
+				APIKey:          "test-key",
+				ShutdownTimeout: 30 * time.Second,
+				Cache:           defaultCache,
+			},
+			wantErrs: []error{ErrInvalidEnvironment},
+		},
+		{
+			name: "invalid rate limit",
 			config: config{
 				DatabaseURL: "postgres://localhost:5432/test",
 				Port:        8080,
-				Environment: "invalid",
+				Environment: "production",
 				APIKey:      "test-key",
+				RateLimit: RateLimitConfig{
+					Providers: map[string]RateConfig{
+						"alpha_vantage": {Requests: 5},
+					},
+				},
+				ShutdownTimeout: 30 * time.Second,
+				Cache:           defaultCache,
 			},
-			wantErrs: []error{ErrInvalidEnvironment},
+			wantErrs: []error{ErrInvalidRateLimit},
+		},
+		{
+			name: "invalid cache",
+			config: config{
+				DatabaseURL:     "postgres://localhost:5432/test",
+				Port:            8080,
+				Environment:     "production",
+				APIKey:          "test-key",
+				ShutdownTimeout: 30 * time.Second,
+				Cache: CacheConfig{
+					RedisURL:  "http://localhost:6379",
+					CandleTTL: time.Minute,
+				},
+			},
+			wantErrs: []error{ErrInvalidCacheURL, ErrInvalidCacheTTL},
+		},
+		{
+			name: "invalid notification channels",
+			config: config{
+				DatabaseURL:     "postgres://localhost:5432/test",
+				Port:            8080,
+				Environment:     "production",
+				APIKey:          "test-key",
+				ShutdownTimeout: 30 * time.Second,
+				Cache:           defaultCache,
+				Notifications: NotificationsConfig{
+					Channels: map[string]ChannelConfig{
+						"pager":  {Type: "pager"},
+						"ops":    {Type: "slack"},
+						"alerts": {Type: "email", SMTPHost: "smtp.example.com", From: "mf@example.com"},
+					},
+				},
+			},
+			wantErrs: []error{ErrInvalidChannel, ErrInvalidChannel, ErrInvalidChannel},
+		},
+		{
+			name: "signing without key file",
+			config: config{
+				DatabaseURL:     "postgres://localhost:5432/test",
+				Port:            8080,
+				Environment:     "production",
+				APIKey:          "test-key",
+				ShutdownTimeout: 30 * time.Second,
+				Cache:           defaultCache,
+				Signing:         SigningConfig{Enabled: true},
+			},
+			wantErrs: []error{ErrMissingSigningKey},
+		},
+		{
+			name: "invalid inbox",
+			config: config{
+				DatabaseURL:     "postgres://localhost:5432/test",
+				Port:            8080,
+				Environment:     "production",
+				APIKey:          "test-key",
+				ShutdownTimeout: 30 * time.Second,
+				Cache:           defaultCache,
+				Inbox: map[string]InboxConfig{
+					"news":   {SignatureHeader: "X-Signature"},
+					"broker": {Secret: "s", SignatureHeader: "X-Signature", Tolerance: -time.Second},
+					"valid":  {Secret: "s", SignatureHeader: "X-Signature"},
+				},
+			},
+			wantErrs: []error{ErrInvalidInbox, ErrInvalidInbox},
+		},
+		{
+			name: "invalid dedupe",
+			config: config{
+				DatabaseURL:     "postgres://localhost:5432/test",
+				Port:            8080,
+				Environment:     "production",
+				APIKey:          "test-key",
+				ShutdownTimeout: 30 * time.Second,
+				Cache:           defaultCache,
+				Notifications: NotificationsConfig{
+					Dedupe: DedupeConfig{TTL: time.Hour, FalsePositiveRate: 1.5},
+				},
+			},
+			wantErrs: []error{ErrInvalidDedupe},
+		},
+		{
+			name: "invalid quiet hours",
+			config: config{
+				DatabaseURL:     "postgres://localhost:5432/test",
+				Port:            8080,
+				Environment:     "production",
+				APIKey:          "test-key",
+				ShutdownTimeout: 30 * time.Second,
+				Cache:           defaultCache,
+				Notifications: NotificationsConfig{
+					Channels: map[string]ChannelConfig{
+						"clock":  {Type: "slack", URL: "https://hooks.slack.com/x", QuietHours: QuietHoursConfig{Start: "22:00", End: "7am"}},
+						"policy": {Type: "slack", URL: "https://hooks.slack.com/x", QuietHours: QuietHoursConfig{Start: "22:00", End: "07:00", Policy: map[string]string{"info": "snooze"}}},
+						"tz":     {Type: "slack", URL: "https://hooks.slack.com/x", QuietHours: QuietHoursConfig{Start: "22:00", End: "07:00", Timezone: "Mars/Olympus"}},
+						"ok":     {Type: "slack", URL: "https://hooks.slack.com/x", QuietHours: QuietHoursConfig{Start: "22:00", End: "07:00", Timezone: "Europe/Helsinki", Policy: map[string]string{"info": "drop"}}},
+					},
+				},
+			},
+			wantErrs: []error{ErrInvalidQuietHours, ErrInvalidQuietHours, ErrInvalidQuietHours},
+		},
+		{
+			name: "invalid digest",
+			config: config{
+				DatabaseURL:     "postgres://localhost:5432/test",
+				Port:            8080,
+				Environment:     "production",
+				APIKey:          "test-key",
+				ShutdownTimeout: 30 * time.Second,
+				Cache:           defaultCache,
+				Notifications: NotificationsConfig{
+					Channels: map[string]ChannelConfig{
+						"interval": {Type: "slack", URL: "https://hooks.slack.com/x", Digest: DigestConfig{Interval: -time.Hour}},
+						"severity": {Type: "slack", URL: "https://hooks.slack.com/x", Digest: DigestConfig{Interval: time.Hour, Severities: []string{"low"}}},
+						"ok":       {Type: "slack", URL: "https://hooks.slack.com/x", Digest: DigestConfig{Interval: time.Hour, Severities: []string{"info", "warning"}}},
+					},
+				},
+			},
+			wantErrs: []error{ErrInvalidDigest, ErrInvalidDigest},
+		},
+		{
+			name: "invalid notification routing",
+			config: config{
+				DatabaseURL:     "postgres://localhost:5432/test",
+				Port:            8080,
+				Environment:     "production",
+				APIKey:          "test-key",
+				ShutdownTimeout: 30 * time.Second,
+				Cache:           defaultCache,
+				Notifications: NotificationsConfig{
+					Channels: map[string]ChannelConfig{
+						"slack": {Type: "slack", URL: "https://hooks.slack.com/x"},
+					},
+					Routing: map[string]SeverityRoute{
+						"critical": {Channels: []string{"slack", "pager"}},
+						"fatal":    {Channels: []string{"slack"}},
+						"info":     {RepeatInterval: -time.Minute},
+					},
+				},
+			},
+			wantErrs: []error{ErrInvalidRouting, ErrInvalidRouting, ErrInvalidRouting},
+		},
+		{
+			name: "simulator outside development",
+			config: config{
+				DatabaseURL:     "postgres://localhost:5432/test",
+				Port:            8080,
+				Environment:     "production",
+				APIKey:          "test-key",
+				ShutdownTimeout: 30 * time.Second,
+				Cache:           defaultCache,
+				Provider:        "simulator",
+				Simulator:       SimulatorConfig{Volatility: -1},
+			},
+			wantErrs: []error{ErrSimulatorOnlyDev, ErrInvalidSimulator},
+		},
+		{
+			name: "unknown provider",
+			config: config{
+				DatabaseURL:     "postgres://localhost:5432/test",
+				Port:            8080,
+				Environment:     "development",
+				APIKey:          "test-key",
+				ShutdownTimeout: 30 * time.Second,
+				Cache:           defaultCache,
+				Provider:        "bloomberg",
+			},
+			wantErrs: []error{ErrInvalidProvider},
+		},
+		{
+			name: "invalid feature name",
+			config: config{
+				DatabaseURL:     "postgres://localhost:5432/test",
+				Port:            8080,
+				Environment:     "production",
+				APIKey:          "test-key",
+				ShutdownTimeout: 30 * time.Second,
+				Cache:           defaultCache,
+				Features:        map[string]bool{"Options-Data": true},
+			},
+			wantErrs: []error{ErrInvalidFeatureName},
+		},
+		{
+			name: "invalid notification health",
+			config: config{
+				DatabaseURL:     "postgres://localhost:5432/test",
+				Port:            8080,
+				Environment:     "production",
+				APIKey:          "test-key",
+				ShutdownTimeout: 30 * time.Second,
+				Cache:           defaultCache,
+				Notifications: NotificationsConfig{
+					Health: HealthConfig{FailureThreshold: 2, AlertChannel: "ops"},
+				},
+			},
+			wantErrs: []error{ErrInvalidHealth, ErrInvalidHealth},
+		},
+		{
+			name: "failover without primary",
+			config: config{
+				DatabaseURL:     "postgres://localhost:5432/test",
+				Port:            8080,
+				Environment:     "development",
+				APIKey:          "test-key",
+				ShutdownTimeout: 30 * time.Second,
+				Cache:           defaultCache,
+				Failover:        FailoverConfig{Providers: []string{"simulator", "bloomberg"}},
+			},
+			wantErrs: []error{ErrInvalidProvider, ErrInvalidFailover},
+		},
+		{
+			name: "shadow without primary",
+			config: config{
+				DatabaseURL:     "postgres://localhost:5432/test",
+				Port:            8080,
+				Environment:     "development",
+				APIKey:          "test-key",
+				ShutdownTimeout: 30 * time.Second,
+				Cache:           defaultCache,
+				Shadow:          ShadowConfig{Provider: "simulator"},
+			},
+			wantErrs: []error{ErrInvalidShadow},
+		},
+		{
+			name: "invalid server settings",
+			config: config{
+				DatabaseURL:     "postgres://localhost:5432/test",
+				Port:            8080,
+				Environment:     "development",
+				APIKey:          "test-key",
+				ShutdownTimeout: 30 * time.Second,
+				Cache:           defaultCache,
+				Server: ServerConfig{DrainPeriod: -time.Second, Limits: LimitsConfig{MaxJSONDepth: -1}, Listeners: []ListenerConfig{
+					{Type: "systemd"},
+					{Type: "unix"},
+					{Type: "quic", Address: ":443"},
+					{Type: "unix", Address: "/run/marketflash.sock", ReusePort: true},
+				}},
+			},
+			wantErrs: []error{ErrInvalidDrain, ErrInvalidLimits, ErrInvalidListener, ErrInvalidListener, ErrInvalidListener},
+		},
+		{
+			name: "invalid routes",
+			config: config{
+				DatabaseURL:     "postgres://localhost:5432/test",
+				Port:            8080,
+				Environment:     "development",
+				APIKey:          "test-key",
+				ShutdownTimeout: 30 * time.Second,
+				Cache:           defaultCache,
+				Routing: []RouteConfig{
+					{Symbols: []string{"[BTC"}, Providers: []string{"simulator"}},
+					{Symbols: []string{"*-USD"}},
+				},
+			},
+			wantErrs: []error{ErrInvalidRoute, ErrInvalidRoute, ErrInvalidRoute},
 		},
 		{
 			name: "missing database_url and invalid port",
 			config: config{
-				Environment: "production",
-				APIKey:      "test-key",
+				Environment:     "production",
+				APIKey:          "test-key",
+				ShutdownTimeout: 30 * time.Second,
+				Cache:           defaultCache,
 			},
 			wantErrs: []error{ErrMissingDatabaseURL, ErrInvalidPortRange},
 		},
 		{
 			name: "invalid environment and missing api_key",
 			config: config{
-				DatabaseURL: "postgres://localhost:5432/test",
-				Port:        8080,
-				Environment: "invalid",
+				DatabaseURL:     "postgres://localhost:5432/test",
+				Port:            8080,
+				Environment:     "invalid",
+				ShutdownTimeout: 30 * time.Second,
+				Cache:           defaultCache,
 			},
 			wantErrs: []error{ErrInvalidEnvironment, ErrMissingAPIKey},
 		},
 		{
 			name: "multiple errors",
 			config: config{
-				Port: 0,
+				Port:            0,
+				ShutdownTimeout: 30 * time.Second,
+				Cache:           defaultCache,
 			},
 			wantErrs: []error{
 				ErrMissingDatabaseURL,
@@ -367,7 +815,7 @@ func TestValidate(t *testing.T) {
 			}
 
 			if len(tt.wantErrs) > 1 {
-				errorCount := strings.Count(errStr, ";") + 1
+				errorCount := strings.Count(errStr, "\n") + 1
 				if errorCount != len(tt.wantErrs) {
 					t.Errorf("expected %d errors, got %d: %v", len(tt.wantErrs), errorCount, err)
 				}
@@ -0,0 +1,196 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// validatedKeys ties validation errors to the key they are about, so a failed
+// validation can name the file or env var the offending value came from.
+var validatedKeys = []struct {
+	err error
+	key string
+}{
+	{ErrInvalidPortRange, "port"},
+	{ErrInvalidEnvironment, "environment"},
+	{ErrInvalidShutdown, "shutdown_timeout"},
+}
+
+// keyedError ties a validation error to the key it is about when its
+// sentinel is shared by several keys, such as ErrInvalidProvider.
+type keyedError struct {
+	key string
+	err error
+}
+
+func (e *keyedError) Error() string { return e.err.Error() }
+func (e *keyedError) Unwrap() error { return e.err }
+
+func keyed(key string, err error) error {
+	return &keyedError{key: key, err: err}
+}
+
+type configFile struct {
+	path string
+	data []byte
+	doc  *yaml.Node // set by parse
+}
+
+// readConfigFile reads path. A missing file yields a zero configFile unless
+// required is set; an empty file is always an error.
+func readConfigFile(path string, required bool) (configFile, error) {
+	data, err := os.ReadFile(path)
+
+	if err != nil && (required || !os.IsNotExist(err)) {
+		return configFile{}, fmt.Errorf("%w: %s", ErrReadConfig, err)
+	}
+
+	if err != nil {
+		return configFile{}, nil
+	}
+
+	if len(data) == 0 {
+		return configFile{}, fmt.Errorf("%w: config file %s is empty", ErrReadConfig, path)
+	}
+
+	return configFile{path: path, data: data}, nil
+}
+
+// parse unmarshals the file into a node tree, decrypting enc: values with
+// dec.
+func (f *configFile) parse(dec *decrypter) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(f.data, &doc); err != nil {
+		return fmt.Errorf("%w: %s: %s", ErrParseYAML, f.path, err)
+	}
+
+	if err := dec.decryptNodes(&doc, ""); err != nil {
+		return fmt.Errorf("%s: %w", f.path, err)
+	}
+
+	f.doc = &doc
+	return nil
+}
+
+// decodeInto unmarshals the file on top of cfg and records the file as the
+// origin of every key it sets. With base set, the file is an overlay: it is
+// deep-merged into base's node tree first, since decoding it alone would
+// replace map values such as notifications.channels.<name> wholesale.
+func (f configFile) decodeInto(cfg *config, base *configFile, origins map[string]string) error {
+	doc := f.doc
+	if base != nil && root(base.doc) != nil && root(f.doc) != nil {
+		mergeNodes(root(base.doc), root(f.doc))
+		doc = base.doc
+	}
+
+	if err := doc.Decode(cfg); err != nil {
+		return fmt.Errorf("%w: %s: %s", ErrParseYAML, f.path, err)
+	}
+
+	if r := root(f.doc); r != nil {
+		recordOrigins(r, "", f.path, origins)
+	}
+
+	return nil
+}
+
+// root returns the top-level mapping of a document, or nil when it has none.
+func root(doc *yaml.Node) *yaml.Node {
+	if doc == nil || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil
+	}
+	return doc.Content[0]
+}
+
+// mergeNodes merges the mapping src into dst. Keys mapping to mappings on
+// both sides are merged recursively; any other src value replaces dst's.
+func mergeNodes(dst, src *yaml.Node) {
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, value := src.Content[i], src.Content[i+1]
+
+		j := mappingIndex(dst, key.Value)
+		switch {
+		case j < 0:
+			dst.Content = append(dst.Content, key, value)
+		case dst.Content[j].Kind == yaml.MappingNode && value.Kind == yaml.MappingNode:
+			mergeNodes(dst.Content[j], value)
+		default:
+			dst.Content[j] = value
+		}
+	}
+}
+
+// mappingIndex returns the index in node.Content of the value for key, or -1.
+func mappingIndex(node *yaml.Node, key string) int {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return i + 1
+		}
+	}
+	return -1
+}
+
+func recordOrigins(node *yaml.Node, prefix, source string, origins map[string]string) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := prefix + node.Content[i].Value
+		origins[key] = source
+		recordOrigins(node.Content[i+1], key+".", source, origins)
+	}
+}
+
+// overlayPathFor derives the environment overlay for base, inserting the
+// environment before the extension: config.yaml -> config.production.yaml.
+func overlayPathFor(base, env string) string {
+	if env == "" {
+		return ""
+	}
+
+	ext := filepath.Ext(base)
+
+	return strings.TrimSuffix(base, ext) + "." + env + ext
+}
+
+func describeOrigins(err error, origins map[string]string) string {
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+
+	var parts []string
+	add := func(key string) {
+		source, ok := origins[key]
+		part := fmt.Sprintf("%s set by %s", key, source)
+		if ok && !slices.Contains(parts, part) {
+			parts = append(parts, part)
+		}
+	}
+
+	for _, e := range errs {
+		var ke *keyedError
+		if errors.As(e, &ke) {
+			add(ke.key)
+			continue
+		}
+		for _, v := range validatedKeys {
+			if errors.Is(e, v.err) {
+				add(v.key)
+			}
+		}
+	}
+
+	if len(parts) == 0 {
+		return ""
+	}
+
+	return " (" + strings.Join(parts, ", ") + ")"
+}
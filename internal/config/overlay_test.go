@@ -0,0 +1,246 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfigFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+	return path
+}
+
+func TestLoadConfigWithOverlay(t *testing.T) {
+	baseContent := `
+database_url: postgres://localhost:5432/base
+port: 8080
+environment: production
+api_key: base-key
+`
+
+	t.Run("overlay selected by environment in base file", func(t *testing.T) {
+		os.Clearenv()
+		dir := t.TempDir()
+		base := writeConfigFile(t, dir, "config.yaml", baseContent)
+		writeConfigFile(t, dir, "config.production.yaml", "port: 9090\n")
+
+		cfg, err := LoadConfig(base)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		want := config{
+			DatabaseURL:     "postgres://localhost:5432/base",
+			Port:            9090,
+			Environment:     "production",
+			APIKey:          "base-key",
+			ShutdownTimeout: 30 * time.Second,
+			Cache:           defaultCache,
+		}
+		if !reflect.DeepEqual(cfg, want) {
+			t.Errorf("expected config %+v, got: %+v", want, cfg)
+		}
+	})
+
+	t.Run("overlay selected by environment variable", func(t *testing.T) {
+		os.Clearenv()
+		setEnv(t, map[string]string{"ENVIRONMENT": "staging"})
+		dir := t.TempDir()
+		base := writeConfigFile(t, dir, "config.yaml", baseContent)
+		writeConfigFile(t, dir, "config.production.yaml", "port: 9090\n")
+		writeConfigFile(t, dir, "config.staging.yaml", "api_key: staging-key\n")
+
+		cfg, err := LoadConfig(base)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if cfg.Port != 8080 || cfg.APIKey != "staging-key" || cfg.Environment != "staging" {
+			t.Errorf("expected staging overlay only, got: %+v", cfg)
+		}
+	})
+
+	t.Run("explicit overlay", func(t *testing.T) {
+		os.Clearenv()
+		dir := t.TempDir()
+		base := writeConfigFile(t, dir, "config.yaml", baseContent)
+		writeConfigFile(t, dir, "config.production.yaml", "port: 9090\n")
+		overlay := writeConfigFile(t, dir, "local.yaml", "port: 7070\n")
+
+		cfg, err := LoadConfigWithOverlay(base, overlay)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if cfg.Port != 7070 {
+			t.Errorf("expected port 7070 from explicit overlay, got: %d", cfg.Port)
+		}
+	})
+
+	t.Run("overlay merges single key inside map entry", func(t *testing.T) {
+		os.Clearenv()
+		dir := t.TempDir()
+		base := writeConfigFile(t, dir, "config.yaml", baseContent+`
+notifications:
+  channels:
+    ops:
+      type: webhook
+      url: https://hooks.example.com/base
+      secret: base-secret
+rate_limit:
+  providers:
+    polygon:
+      requests: 5
+      per: 1s
+`)
+		writeConfigFile(t, dir, "config.production.yaml", `
+notifications:
+  channels:
+    ops:
+      url: https://hooks.example.com/production
+rate_limit:
+  providers:
+    polygon:
+      burst: 10
+`)
+
+		cfg, err := LoadConfig(base)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+
+		wantChannel := ChannelConfig{Type: "webhook", URL: "https://hooks.example.com/production", Secret: "base-secret"}
+		if got := cfg.Notifications.Channels["ops"]; !reflect.DeepEqual(got, wantChannel) {
+			t.Errorf("expected channel %+v, got: %+v", wantChannel, got)
+		}
+		wantRate := RateConfig{Requests: 5, Per: time.Second, Burst: 10}
+		if got := cfg.RateLimit.Providers["polygon"]; got != wantRate {
+			t.Errorf("expected rate %+v, got: %+v", wantRate, got)
+		}
+		if cfg.DatabaseURL != "postgres://localhost:5432/base" {
+			t.Errorf("expected base values to be kept, got: %+v", cfg)
+		}
+	})
+
+	t.Run("missing explicit overlay", func(t *testing.T) {
+		os.Clearenv()
+		dir := t.TempDir()
+		base := writeConfigFile(t, dir, "config.yaml", baseContent)
+
+		_, err := LoadConfigWithOverlay(base, filepath.Join(dir, "missing.yaml"))
+		if !errors.Is(err, ErrReadConfig) {
+			t.Errorf("expected error %v, got: %v", ErrReadConfig, err)
+		}
+	})
+
+	t.Run("env overrides overlay", func(t *testing.T) {
+		os.Clearenv()
+		setEnv(t, map[string]string{"PORT": "6060"})
+		dir := t.TempDir()
+		base := writeConfigFile(t, dir, "config.yaml", baseContent)
+		writeConfigFile(t, dir, "config.production.yaml", "port: 9090\n")
+
+		cfg, err := LoadConfig(base)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if cfg.Port != 6060 {
+			t.Errorf("expected port 6060 from env, got: %d", cfg.Port)
+		}
+	})
+
+	t.Run("parse error names overlay file", func(t *testing.T) {
+		os.Clearenv()
+		dir := t.TempDir()
+		base := writeConfigFile(t, dir, "config.yaml", baseContent)
+		overlay := writeConfigFile(t, dir, "config.production.yaml", "port: not-a-number\n")
+
+		_, err := LoadConfig(base)
+		if !errors.Is(err, ErrParseYAML) {
+			t.Fatalf("expected error %v, got: %v", ErrParseYAML, err)
+		}
+		if !strings.Contains(err.Error(), overlay) {
+			t.Errorf("expected error to name %s, got: %v", overlay, err)
+		}
+	})
+
+	t.Run("validation error names overlay file", func(t *testing.T) {
+		os.Clearenv()
+		dir := t.TempDir()
+		base := writeConfigFile(t, dir, "config.yaml", baseContent)
+		overlay := writeConfigFile(t, dir, "config.production.yaml", "port: 70000\n")
+
+		_, err := LoadConfig(base)
+		if !errors.Is(err, ErrValidationFailed) {
+			t.Fatalf("expected error %v, got: %v", ErrValidationFailed, err)
+		}
+		if !strings.Contains(err.Error(), "port set by "+overlay) {
+			t.Errorf("expected error to name %s as source of port, got: %v", overlay, err)
+		}
+	})
+
+	t.Run("validation error names the provider key at fault", func(t *testing.T) {
+		os.Clearenv()
+		dir := t.TempDir()
+		base := writeConfigFile(t, dir, "config.yaml", `
+database_url: postgres://localhost:5432/base
+environment: development
+api_key: base-key
+provider: simulator
+`)
+		overlay := writeConfigFile(t, dir, "config.development.yaml", "failover:\n  providers: [bogus]\n")
+
+		_, err := LoadConfig(base)
+		if !errors.Is(err, ErrValidationFailed) {
+			t.Fatalf("expected error %v, got: %v", ErrValidationFailed, err)
+		}
+		if !strings.Contains(err.Error(), "failover.providers set by "+overlay) {
+			t.Errorf("expected error to name %s as source of failover.providers, got: %v", overlay, err)
+		}
+		if strings.Contains(err.Error(), "provider set by "+base) {
+			t.Errorf("expected error not to blame provider, got: %v", err)
+		}
+	})
+
+	t.Run("strict mode checks overlay", func(t *testing.T) {
+		os.Clearenv()
+		dir := t.TempDir()
+		base := writeConfigFile(t, dir, "config.yaml", baseContent)
+		overlay := writeConfigFile(t, dir, "config.production.yaml", "prot: 9090\n")
+
+		_, err := LoadConfig(base)
+		if !errors.Is(err, ErrUnknownField) {
+			t.Fatalf("expected error %v, got: %v", ErrUnknownField, err)
+		}
+		if !strings.Contains(err.Error(), overlay) {
+			t.Errorf("expected error to name %s, got: %v", overlay, err)
+		}
+	})
+}
+
+func TestOverlayPathFor(t *testing.T) {
+	tests := []struct {
+		base string
+		env  string
+		want string
+	}{
+		{base: "config.yaml", env: "production", want: "config.production.yaml"},
+		{base: "/etc/marketflash/config.yml", env: "staging", want: "/etc/marketflash/config.staging.yml"},
+		{base: "config", env: "development", want: "config.development"},
+		{base: "config.yaml", env: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.base+"/"+tt.env, func(t *testing.T) {
+			if got := overlayPathFor(tt.base, tt.env); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"net/url"
+	"reflect"
+)
+
+const redacted = "REDACTED"
+
+// Redacted returns a copy of c that is safe to show operators. String fields
+// tagged `redact:"true"` are replaced when set; fields tagged `redact:"url"`
+// keep the URL but lose its password.
+func (c config) Redacted() config {
+	v := reflect.ValueOf(&c).Elem()
+	redactValue(v)
+	return c
+}
+
+func redactValue(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := range t.NumField() {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			field := v.Field(i)
+
+			switch f.Tag.Get("redact") {
+			case "true":
+				redactString(field, func(string) string { return redacted })
+			case "url":
+				redactString(field, redactURL)
+			default:
+				redactValue(field)
+			}
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			return
+		}
+		// Map values are not addressable, so redact copies into a new map
+		// rather than mutating one shared with the original config.
+		out := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(iter.Value())
+			redactValue(elem)
+			out.SetMapIndex(iter.Key(), elem)
+		}
+		v.Set(out)
+	}
+}
+
+func redactString(v reflect.Value, redact func(string) string) {
+	if v.Kind() == reflect.String && v.String() != "" {
+		v.SetString(redact(v.String()))
+	}
+}
+
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return redacted
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), redacted)
+	}
+	return u.String()
+}
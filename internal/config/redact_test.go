@@ -0,0 +1,40 @@
+package config
+
+import "testing"
+
+func TestRedacted(t *testing.T) {
+	cfg := config{
+		DatabaseURL: "postgres://app:hunter2@db:5432/marketflash",
+		APIKey:      "root-key",
+		Port:        8080,
+		Cache:       CacheConfig{RedisURL: "redis://:pw@cache:6379/0"},
+		Notifications: NotificationsConfig{
+			Channels: map[string]ChannelConfig{
+				"slack": {Type: "slack", URL: "https://hooks.slack.com/services/T/B/x"},
+				"mail":  {Type: "email", Username: "alerts", Password: "smtp-pass"},
+			},
+		},
+	}
+
+	got := cfg.Redacted()
+
+	if got.DatabaseURL != "postgres://app:REDACTED@db:5432/marketflash" {
+		t.Errorf("expected database password redacted, got %q", got.DatabaseURL)
+	}
+	if got.Cache.RedisURL != "redis://:REDACTED@cache:6379/0" {
+		t.Errorf("expected redis password redacted, got %q", got.Cache.RedisURL)
+	}
+	if got.APIKey != "REDACTED" || got.Port != 8080 {
+		t.Errorf("expected only secrets redacted, got api_key %q port %d", got.APIKey, got.Port)
+	}
+	if ch := got.Notifications.Channels["slack"]; ch.URL != "REDACTED" || ch.Type != "slack" {
+		t.Errorf("unexpected slack channel: %+v", ch)
+	}
+	if ch := got.Notifications.Channels["mail"]; ch.Password != "REDACTED" || ch.Username != "alerts" || ch.Secret != "" {
+		t.Errorf("unexpected mail channel: %+v", ch)
+	}
+
+	if cfg.APIKey != "root-key" || cfg.Notifications.Channels["mail"].Password != "smtp-pass" {
+		t.Errorf("expected original config to be untouched, got %+v", cfg)
+	}
+}
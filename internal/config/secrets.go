@@ -0,0 +1,160 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrMissingConfigKey = errors.New("config has encrypted values but neither CONFIG_KEY nor CONFIG_KEY_FILE is set")
+	ErrInvalidConfigKey = errors.New("config key must be 32 bytes, base64 encoded")
+	ErrDecryptValue     = errors.New("unable to decrypt config value")
+)
+
+// Encrypted values look like enc:<scheme>:<payload>. The only scheme is
+// aes256gcm, whose payload is base64(nonce || ciphertext); the scheme label
+// leaves room for age or KMS ciphertexts later.
+const (
+	encryptedPrefix = "enc:"
+	schemeAESGCM    = "aes256gcm"
+)
+
+// EncryptValue encrypts plaintext with key for use as an enc: config value.
+// Operators produce values with cmd/encrypt-config.
+func EncryptValue(key []byte, plaintext string) (string, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+
+	return encryptedPrefix + schemeAESGCM + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decrypter decrypts enc: values, loading the key from CONFIG_KEY or the file
+// named by CONFIG_KEY_FILE the first time one is seen, so configs without
+// encrypted values need no key.
+type decrypter struct {
+	aead cipher.AEAD
+}
+
+// decryptNodes replaces every enc: scalar under node with its plaintext. Errors
+// name the key path but never the value.
+func (d *decrypter) decryptNodes(node *yaml.Node, path string) error {
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for i, child := range node.Content {
+			childPath := path
+			if node.Kind == yaml.SequenceNode {
+				childPath = fmt.Sprintf("%s[%d]", path, i)
+			}
+			if err := d.decryptNodes(child, childPath); err != nil {
+				return err
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			if path != "" {
+				key = path + "." + key
+			}
+			if err := d.decryptNodes(node.Content[i+1], key); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		if !strings.HasPrefix(node.Value, encryptedPrefix) {
+			return nil
+		}
+		plaintext, err := d.decrypt(node.Value)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %w", ErrDecryptValue, path, err)
+		}
+		node.Value = plaintext
+		node.Tag = "!!str"
+		node.Style = 0
+	}
+
+	return nil
+}
+
+func (d *decrypter) decrypt(value string) (string, error) {
+	scheme, payload, ok := strings.Cut(strings.TrimPrefix(value, encryptedPrefix), ":")
+	if !ok || scheme != schemeAESGCM {
+		return "", fmt.Errorf("unsupported scheme %q", scheme)
+	}
+
+	if d.aead == nil {
+		key, err := LoadConfigKey()
+		if err != nil {
+			return "", err
+		}
+		if d.aead, err = newAEAD(key); err != nil {
+			return "", err
+		}
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil || len(sealed) < d.aead.NonceSize() {
+		return "", errors.New("malformed ciphertext")
+	}
+
+	nonce, ciphertext := sealed[:d.aead.NonceSize()], sealed[d.aead.NonceSize():]
+	plaintext, err := d.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", errors.New("wrong key or corrupted ciphertext")
+	}
+
+	return string(plaintext), nil
+}
+
+// LoadConfigKey returns the key encrypted values are decrypted with: the
+// base64 in CONFIG_KEY, or in the file named by CONFIG_KEY_FILE.
+func LoadConfigKey() ([]byte, error) {
+	encoded, ok := os.LookupEnv("CONFIG_KEY")
+	if !ok {
+		path, ok := os.LookupEnv("CONFIG_KEY_FILE")
+		if !ok {
+			return nil, ErrMissingConfigKey
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidConfigKey, err)
+		}
+		encoded = string(data)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidConfigKey, err)
+	}
+
+	return key, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("%w: got %d bytes", ErrInvalidConfigKey, len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidConfigKey, err)
+	}
+
+	return cipher.NewGCM(block)
+}
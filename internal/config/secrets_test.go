@@ -0,0 +1,129 @@
+package config
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestEncryptedValues(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	encodedKey := base64.StdEncoding.EncodeToString(key)
+
+	apiKey, err := EncryptValue(key, "s3cret-api-key")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	password, err := EncryptValue(key, "smtp-pass")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	content := `
+database_url: postgres://localhost:5432/test
+api_key: ` + apiKey + `
+notifications:
+  channels:
+    mail:
+      type: email
+      smtp_host: smtp.example.com
+      from: alerts@example.com
+      to: [ops@example.com]
+      password: "` + password + `"
+`
+
+	t.Run("decrypts with CONFIG_KEY", func(t *testing.T) {
+		os.Clearenv()
+		setEnv(t, map[string]string{"CONFIG_KEY": encodedKey})
+
+		cfg, err := LoadConfig(createTempConfigFile(t, content))
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if cfg.APIKey != "s3cret-api-key" {
+			t.Errorf("expected decrypted api_key, got %q", cfg.APIKey)
+		}
+		if got := cfg.Notifications.Channels["mail"].Password; got != "smtp-pass" {
+			t.Errorf("expected decrypted password, got %q", got)
+		}
+	})
+
+	t.Run("decrypts with CONFIG_KEY_FILE", func(t *testing.T) {
+		os.Clearenv()
+		keyFile := writeConfigFile(t, t.TempDir(), "config.key", encodedKey+"\n")
+		setEnv(t, map[string]string{"CONFIG_KEY_FILE": keyFile})
+
+		cfg, err := LoadConfig(createTempConfigFile(t, content))
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if cfg.APIKey != "s3cret-api-key" {
+			t.Errorf("expected decrypted api_key, got %q", cfg.APIKey)
+		}
+	})
+
+	t.Run("env overrides stay plaintext", func(t *testing.T) {
+		os.Clearenv()
+		setEnv(t, map[string]string{"CONFIG_KEY": encodedKey, "API_KEY": "from-env"})
+
+		cfg, err := LoadConfig(createTempConfigFile(t, content))
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if cfg.APIKey != "from-env" {
+			t.Errorf("expected env api_key, got %q", cfg.APIKey)
+		}
+	})
+
+	wrongKey := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{8}, 32))
+	tests := []struct {
+		name    string
+		env     map[string]string
+		content string
+		wantErr error
+	}{
+		{
+			name:    "missing key",
+			content: content,
+			wantErr: ErrMissingConfigKey,
+		},
+		{
+			name:    "short key",
+			env:     map[string]string{"CONFIG_KEY": base64.StdEncoding.EncodeToString([]byte("short"))},
+			content: content,
+			wantErr: ErrInvalidConfigKey,
+		},
+		{
+			name:    "wrong key",
+			env:     map[string]string{"CONFIG_KEY": wrongKey},
+			content: content,
+			wantErr: ErrDecryptValue,
+		},
+		{
+			name:    "unknown scheme",
+			env:     map[string]string{"CONFIG_KEY": encodedKey},
+			content: "database_url: postgres://localhost/test\napi_key: enc:kms:abc\n",
+			wantErr: ErrDecryptValue,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Clearenv()
+			setEnv(t, tt.env)
+
+			_, err := LoadConfig(createTempConfigFile(t, tt.content))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got: %v", tt.wantErr, err)
+			}
+			if !strings.Contains(err.Error(), "api_key") {
+				t.Errorf("expected error to name the key, got: %v", err)
+			}
+			if strings.Contains(err.Error(), apiKey) {
+				t.Errorf("expected error not to include the ciphertext, got: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// unknownFields reports every mapping key in data that has no matching yaml
+// tag in t, descending into nested sections, map values and list items. Each entry names the key
+// path and line, plus the closest valid key when one is near enough to be a
+// likely typo.
+func unknownFields(data []byte, t reflect.Type) ([]string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	var found []string
+	if len(doc.Content) > 0 {
+		walkUnknown(doc.Content[0], t, "", &found)
+	}
+
+	return found, nil
+}
+
+// walkUnknown checks node against t. path names node in reported keys:
+// struct fields and map values extend it as path.key, and sequence items as
+// path[i].
+func walkUnknown(node *yaml.Node, t reflect.Type, path string, found *[]string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case node.Kind == yaml.MappingNode && t.Kind() == reflect.Map:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			walkUnknown(node.Content[i+1], t.Elem(), joinKey(path, node.Content[i].Value), found)
+		}
+		return
+	case node.Kind == yaml.SequenceNode && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array):
+		for i, item := range node.Content {
+			walkUnknown(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), found)
+		}
+		return
+	case node.Kind != yaml.MappingNode || t.Kind() != reflect.Struct:
+		return
+	}
+
+	fields := yamlFields(t)
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+
+		fieldType, ok := fields[key.Value]
+		if !ok {
+			msg := fmt.Sprintf("%q at line %d", joinKey(path, key.Value), key.Line)
+			if suggestion := closestName(key.Value, names); suggestion != "" {
+				msg += fmt.Sprintf(" (did you mean %q?)", joinKey(path, suggestion))
+			}
+			*found = append(*found, msg)
+			continue
+		}
+
+		walkUnknown(value, fieldType, joinKey(path, key.Value), found)
+	}
+}
+
+func joinKey(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// yamlFields maps the yaml key of every exported field in t to its type.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type, t.NumField())
+
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f.Type
+	}
+
+	return fields
+}
+
+// closestName returns the candidate with the smallest edit distance to name,
+// or "" when nothing is close enough to be a plausible typo.
+func closestName(name string, candidates []string) string {
+	best, bestDist := "", -1
+
+	for _, c := range candidates {
+		d := levenshtein(name, c)
+		if bestDist == -1 || d < bestDist || (d == bestDist && c < best) {
+			best, bestDist = c, d
+		}
+	}
+
+	if bestDist == -1 || bestDist > max(2, len(name)/3) {
+		return ""
+	}
+
+	return best
+}
+
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
@@ -0,0 +1,158 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestStrictMode(t *testing.T) {
+	typoConfig := `
+databse_url: postgres://localhost:5432/test
+port: 8080
+environment: production
+api_key: test-key
+`
+
+	t.Run("production rejects unknown key with suggestion", func(t *testing.T) {
+		os.Clearenv()
+		setEnv(t, map[string]string{"DATABASE_URL": "postgres://localhost:5432/test"})
+
+		path := createTempConfigFile(t, typoConfig)
+
+		_, err := LoadConfig(path)
+		if !errors.Is(err, ErrUnknownField) {
+			t.Fatalf("expected error %v, got: %v", ErrUnknownField, err)
+		}
+		if !strings.Contains(err.Error(), `did you mean "database_url"?`) {
+			t.Errorf("expected suggestion for database_url, got: %v", err)
+		}
+		if !strings.Contains(err.Error(), "line 2") {
+			t.Errorf("expected line number in error, got: %v", err)
+		}
+	})
+
+	t.Run("development ignores unknown key", func(t *testing.T) {
+		os.Clearenv()
+		setEnv(t, map[string]string{
+			"DATABASE_URL": "postgres://localhost:5432/test",
+			"ENVIRONMENT":  "development",
+		})
+
+		path := createTempConfigFile(t, typoConfig)
+
+		if _, err := LoadConfig(path); err != nil {
+			t.Errorf("expected no error, got: %v", err)
+		}
+	})
+
+	t.Run("env enables strict outside production", func(t *testing.T) {
+		os.Clearenv()
+		setEnv(t, map[string]string{
+			"DATABASE_URL":  "postgres://localhost:5432/test",
+			"ENVIRONMENT":   "staging",
+			"STRICT_CONFIG": "true",
+		})
+
+		path := createTempConfigFile(t, typoConfig)
+
+		_, err := LoadConfig(path)
+		if !errors.Is(err, ErrUnknownField) {
+			t.Errorf("expected error %v, got: %v", ErrUnknownField, err)
+		}
+	})
+
+	t.Run("env disables strict in production", func(t *testing.T) {
+		os.Clearenv()
+		setEnv(t, map[string]string{
+			"DATABASE_URL":  "postgres://localhost:5432/test",
+			"STRICT_CONFIG": "false",
+		})
+
+		path := createTempConfigFile(t, typoConfig)
+
+		if _, err := LoadConfig(path); err != nil {
+			t.Errorf("expected no error, got: %v", err)
+		}
+	})
+
+	t.Run("rejects unknown key inside map value", func(t *testing.T) {
+		os.Clearenv()
+		setEnv(t, map[string]string{"DATABASE_URL": "postgres://localhost:5432/test"})
+
+		path := createTempConfigFile(t, `
+environment: production
+api_key: test-key
+notifications:
+  channels:
+    ops:
+      type: webhook
+      secert: x
+`)
+
+		_, err := LoadConfig(path)
+		if !errors.Is(err, ErrUnknownField) {
+			t.Fatalf("expected error %v, got: %v", ErrUnknownField, err)
+		}
+		if !strings.Contains(err.Error(), `"notifications.channels.ops.secert" at line 8`) {
+			t.Errorf("expected map value key path in error, got: %v", err)
+		}
+	})
+
+	t.Run("rejects unknown key inside list item", func(t *testing.T) {
+		os.Clearenv()
+		setEnv(t, map[string]string{"DATABASE_URL": "postgres://localhost:5432/test"})
+
+		path := createTempConfigFile(t, `
+environment: production
+api_key: test-key
+server:
+  listeners:
+    - type: tcp
+      address: ":8080"
+      reuseport: true
+`)
+
+		_, err := LoadConfig(path)
+		if !errors.Is(err, ErrUnknownField) {
+			t.Fatalf("expected error %v, got: %v", ErrUnknownField, err)
+		}
+		if !strings.Contains(err.Error(), `"server.listeners[0].reuseport" at line 8`) {
+			t.Errorf("expected list item key path in error, got: %v", err)
+		}
+	})
+
+	t.Run("invalid strict env", func(t *testing.T) {
+		os.Clearenv()
+		setEnv(t, map[string]string{"STRICT_CONFIG": "maybe"})
+
+		_, err := LoadConfig("")
+		if !errors.Is(err, ErrInvalidStrict) {
+			t.Errorf("expected error %v, got: %v", ErrInvalidStrict, err)
+		}
+	})
+}
+
+func TestClosestName(t *testing.T) {
+	candidates := []string{"database_url", "port", "environment", "api_key", "debug"}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "databse_url", want: "database_url"},
+		{name: "enviroment", want: "environment"},
+		{name: "apikey", want: "api_key"},
+		{name: "prot", want: "port"},
+		{name: "completely_unrelated", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := closestName(tt.name, candidates); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package flags
+
+import (
+	"maps"
+	"sync/atomic"
+)
+
+// Flags answers whether features are enabled. It is safe for concurrent use,
+// and Update swaps in a new set atomically so flags can be reloaded without
+// restarting.
+type Flags struct {
+	features atomic.Pointer[map[string]bool]
+}
+
+// New returns Flags for the features config section.
+func New(features map[string]bool) *Flags {
+	f := &Flags{}
+	f.Update(features)
+	return f
+}
+
+// Enabled reports whether the feature name is on. Unknown features are off,
+// so code can check a flag before it appears in any config.
+func (f *Flags) Enabled(name string) bool {
+	return (*f.features.Load())[name]
+}
+
+// Update replaces every flag with features.
+func (f *Flags) Update(features map[string]bool) {
+	cloned := maps.Clone(features)
+	if cloned == nil {
+		cloned = map[string]bool{}
+	}
+	f.features.Store(&cloned)
+}
+
+// All returns a copy of the current flags.
+func (f *Flags) All() map[string]bool {
+	return maps.Clone(*f.features.Load())
+}
@@ -0,0 +1,42 @@
+package flags
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFlags(t *testing.T) {
+	features := map[string]bool{"options_data": true, "new_aggregator": false}
+	f := New(features)
+
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"options_data", true},
+		{"new_aggregator", false},
+		{"unknown", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := f.Enabled(tt.name); got != tt.want {
+				t.Errorf("expected %s enabled=%v, got %v", tt.name, tt.want, got)
+			}
+		})
+	}
+
+	features["unknown"] = true
+	if f.Enabled("unknown") {
+		t.Error("expected flags to be isolated from the caller's map")
+	}
+
+	f.Update(map[string]bool{"new_aggregator": true})
+	if f.Enabled("options_data") || !f.Enabled("new_aggregator") {
+		t.Errorf("expected update to replace flags, got %v", f.All())
+	}
+
+	f.Update(nil)
+	if got := f.All(); !reflect.DeepEqual(got, map[string]bool{}) {
+		t.Errorf("expected no flags, got %v", got)
+	}
+}
@@ -0,0 +1,211 @@
+package inbox
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"marketflash/internal/config"
+	"marketflash/internal/validate"
+)
+
+var (
+	ErrUnknownProvider = errors.New("unknown callback provider")
+	ErrBadSignature    = errors.New("callback signature verification failed")
+	ErrStaleCallback   = errors.New("callback timestamp outside tolerance")
+	ErrInvalidPayload  = errors.New("invalid callback payload")
+)
+
+const (
+	defaultTolerance = 5 * time.Minute
+
+	// maxCallbackBytes bounds a callback body.
+	maxCallbackBytes = 1 << 20
+)
+
+// Callback is a provider callback normalized for the rest of the service.
+type Callback struct {
+	Provider   string          `json:"provider"`
+	Type       string          `json:"type"`
+	ReceivedAt time.Time       `json:"received_at"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+// Sink receives verified callbacks. An error makes the inbox answer 500, so
+// the provider retries delivery; callbacks received before the error are
+// delivered again, so sinks must tolerate duplicates.
+type Sink interface {
+	Receive(ctx context.Context, cb Callback) error
+}
+
+// Normalizer turns a verified callback body into callbacks. Provider and
+// ReceivedAt are filled in by the inbox.
+type Normalizer func(body []byte) ([]Callback, error)
+
+type provider struct {
+	cfg       config.InboxConfig
+	secret    []byte
+	normalize Normalizer
+}
+
+// Inbox verifies callbacks pushed by upstream providers, such as news feeds
+// and broker fill notifications, and hands them to a sink.
+type Inbox struct {
+	providers map[string]provider
+	sink      Sink
+	now       func() time.Time
+}
+
+// New returns an inbox accepting callbacks from the providers in cfg. Bodies
+// are normalized with NormalizeJSON until SetNormalizer says otherwise.
+func New(cfg map[string]config.InboxConfig, sink Sink) *Inbox {
+	in := &Inbox{
+		providers: make(map[string]provider, len(cfg)),
+		sink:      sink,
+		now:       time.Now,
+	}
+
+	for name, c := range cfg {
+		in.providers[name] = provider{cfg: c, secret: []byte(c.Secret), normalize: NormalizeJSON}
+	}
+
+	return in
+}
+
+// SetNormalizer sets how callbacks from the provider name are normalized. It
+// must not be called once the inbox is in use.
+func (in *Inbox) SetNormalizer(name string, fn Normalizer) error {
+	p, ok := in.providers[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownProvider, name)
+	}
+
+	p.normalize = fn
+	in.providers[name] = p
+	return nil
+}
+
+// NormalizeJSON accepts a JSON object as a single callback, taking its type
+// from a top-level "type" or "event" field.
+func NormalizeJSON(body []byte) ([]Callback, error) {
+	var fields struct {
+		Type  string `json:"type"`
+		Event string `json:"event"`
+	}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPayload, err)
+	}
+
+	typ := fields.Type
+	if typ == "" {
+		typ = fields.Event
+	}
+
+	return []Callback{{Type: typ, Payload: json.RawMessage(body)}}, nil
+}
+
+// Verify checks the signature headers on a callback from the provider name.
+func (in *Inbox) Verify(name string, header http.Header, body []byte) error {
+	p, ok := in.providers[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownProvider, name)
+	}
+
+	mac := hmac.New(sha256.New, p.secret)
+
+	if p.cfg.TimestampHeader != "" {
+		ts := header.Get(p.cfg.TimestampHeader)
+		sec, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: missing or malformed %s", ErrBadSignature, p.cfg.TimestampHeader)
+		}
+
+		tolerance := p.cfg.Tolerance
+		if tolerance == 0 {
+			tolerance = defaultTolerance
+		}
+		if skew := in.now().Unix() - sec; math.Abs(float64(skew)) > tolerance.Seconds() {
+			return fmt.Errorf("%w: %ds", ErrStaleCallback, skew)
+		}
+
+		mac.Write([]byte(ts))
+		mac.Write([]byte("."))
+	}
+	mac.Write(body)
+
+	got, err := hex.DecodeString(strings.TrimPrefix(header.Get(p.cfg.SignatureHeader), "sha256="))
+	if err != nil || !hmac.Equal(got, mac.Sum(nil)) {
+		return ErrBadSignature
+	}
+
+	return nil
+}
+
+// Handler serves the callback endpoint:
+//
+//	POST /v1/inbox/{provider}  receive a callback from provider
+//
+// Callbacks are authenticated by their signature alone, so it must not be
+// mounted behind auth.Manager.Middleware. Verified callbacks are answered
+// with 202 once the sink accepts them.
+func (in *Inbox) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("POST /v1/inbox/{provider}", func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("provider")
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxCallbackBytes))
+		if err != nil {
+			validate.WriteProblem(w, validate.Problem{Status: http.StatusRequestEntityTooLarge, Detail: err.Error(), MaxBytes: maxCallbackBytes})
+			return
+		}
+
+		if err := in.Verify(name, r.Header, body); err != nil {
+			writeError(w, err)
+			return
+		}
+
+		callbacks, err := in.providers[name].normalize(body)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		receivedAt := in.now().UTC()
+		for _, cb := range callbacks {
+			cb.Provider = name
+			cb.ReceivedAt = receivedAt
+			if err := in.sink.Receive(r.Context(), cb); err != nil {
+				writeError(w, err)
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	return mux
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrUnknownProvider):
+		validate.WriteStatus(w, http.StatusNotFound, err)
+	case errors.Is(err, ErrBadSignature), errors.Is(err, ErrStaleCallback):
+		validate.WriteStatus(w, http.StatusUnauthorized, err)
+	case errors.Is(err, ErrInvalidPayload):
+		validate.WriteStatus(w, http.StatusBadRequest, err)
+	default:
+		validate.WriteStatus(w, http.StatusInternalServerError, err)
+	}
+}
@@ -0,0 +1,196 @@
+package inbox
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"marketflash/internal/config"
+)
+
+type fakeSink struct {
+	received []Callback
+	err      error
+}
+
+func (s *fakeSink) Receive(ctx context.Context, cb Callback) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.received = append(s.received, cb)
+	return nil
+}
+
+func sign(secret, prefix, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(prefix + body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func newTestInbox(sink Sink) *Inbox {
+	in := New(map[string]config.InboxConfig{
+		"news": {Secret: "news-secret", SignatureHeader: "X-News-Signature"},
+		"broker": {
+			Secret:          "broker-secret",
+			SignatureHeader: "X-Broker-Signature",
+			TimestampHeader: "X-Broker-Timestamp",
+			Tolerance:       time.Minute,
+		},
+	}, sink)
+	in.now = func() time.Time { return time.Unix(1700000000, 0) }
+	return in
+}
+
+func TestHandler(t *testing.T) {
+	const body = `{"event":"fill","order_id":"o-1","symbol":"AAPL"}`
+	ts := "1700000000"
+
+	tests := []struct {
+		name     string
+		provider string
+		header   map[string]string
+		body     string
+		want     int
+	}{
+		{
+			name:     "signed",
+			provider: "news",
+			header:   map[string]string{"X-News-Signature": "sha256=" + sign("news-secret", "", body)},
+			body:     body,
+			want:     http.StatusAccepted,
+		},
+		{
+			name:     "signed with timestamp",
+			provider: "broker",
+			header: map[string]string{
+				"X-Broker-Signature": sign("broker-secret", ts+".", body),
+				"X-Broker-Timestamp": ts,
+			},
+			body: body,
+			want: http.StatusAccepted,
+		},
+		{
+			name:     "wrong secret",
+			provider: "news",
+			header:   map[string]string{"X-News-Signature": sign("broker-secret", "", body)},
+			body:     body,
+			want:     http.StatusUnauthorized,
+		},
+		{
+			name:     "stale timestamp",
+			provider: "broker",
+			header: map[string]string{
+				"X-Broker-Signature": sign("broker-secret", "1699999000.", body),
+				"X-Broker-Timestamp": "1699999000",
+			},
+			body: body,
+			want: http.StatusUnauthorized,
+		},
+		{
+			name:     "unknown provider",
+			provider: "other",
+			body:     body,
+			want:     http.StatusNotFound,
+		},
+		{
+			name:     "not json",
+			provider: "news",
+			header:   map[string]string{"X-News-Signature": sign("news-secret", "", "nope")},
+			body:     "nope",
+			want:     http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := &fakeSink{}
+			in := newTestInbox(sink)
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/inbox/"+tt.provider, strings.NewReader(tt.body))
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			in.Handler().ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("expected status %d, got %d: %s", tt.want, rec.Code, rec.Body)
+			}
+			if tt.want != http.StatusAccepted {
+				if len(sink.received) != 0 {
+					t.Errorf("expected nothing delivered, got %+v", sink.received)
+				}
+				return
+			}
+
+			if len(sink.received) != 1 {
+				t.Fatalf("expected one callback, got %+v", sink.received)
+			}
+			cb := sink.received[0]
+			if cb.Provider != tt.provider || cb.Type != "fill" || string(cb.Payload) != body {
+				t.Errorf("unexpected callback %+v", cb)
+			}
+			if !cb.ReceivedAt.Equal(time.Unix(1700000000, 0)) {
+				t.Errorf("expected received_at from the clock, got %s", cb.ReceivedAt)
+			}
+		})
+	}
+}
+
+func TestHandlerNormalizerAndSinkError(t *testing.T) {
+	sink := &fakeSink{}
+	in := newTestInbox(sink)
+
+	err := in.SetNormalizer("news", func(body []byte) ([]Callback, error) {
+		return []Callback{{Type: "headline"}, {Type: "headline"}}, nil
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if err := in.SetNormalizer("other", NormalizeJSON); !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("expected error %v, got: %v", ErrUnknownProvider, err)
+	}
+
+	post := func() int {
+		req := httptest.NewRequest(http.MethodPost, "/v1/inbox/news", strings.NewReader("batch"))
+		req.Header.Set("X-News-Signature", sign("news-secret", "", "batch"))
+		rec := httptest.NewRecorder()
+		in.Handler().ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := post(); code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", code)
+	}
+	if len(sink.received) != 2 {
+		t.Errorf("expected both normalized callbacks, got %+v", sink.received)
+	}
+
+	sink.err = errors.New("pipeline unavailable")
+	if code := post(); code != http.StatusInternalServerError {
+		t.Errorf("expected 500 so the provider retries, got %d", code)
+	}
+}
+
+func TestVerifyMissingTimestamp(t *testing.T) {
+	in := newTestInbox(&fakeSink{})
+
+	header := http.Header{}
+	header.Set("X-Broker-Signature", sign("broker-secret", "", "{}"))
+	if err := in.Verify("broker", header, []byte("{}")); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("expected error %v, got: %v", ErrBadSignature, err)
+	}
+
+	header.Set("X-Broker-Timestamp", strconv.FormatInt(time.Unix(1700000000, 0).Add(30*time.Second).Unix(), 10))
+	header.Set("X-Broker-Signature", sign("broker-secret", header.Get("X-Broker-Timestamp")+".", "{}"))
+	if err := in.Verify("broker", header, []byte("{}")); err != nil {
+		t.Errorf("expected a timestamp within tolerance to verify, got: %v", err)
+	}
+}
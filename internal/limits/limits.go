@@ -0,0 +1,199 @@
+package limits
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"marketflash/internal/config"
+	"marketflash/internal/validate"
+)
+
+const (
+	defaultMaxBodyBytes          = 1 << 20
+	defaultMaxDecompressedBytes  = 10 << 20
+	defaultMaxDecompressionRatio = 100
+	defaultMaxJSONDepth          = 32
+)
+
+var (
+	ErrBodyTooLarge        = errors.New("request body too large")
+	ErrDecompressionRatio  = errors.New("request body compression ratio too high")
+	ErrUnsupportedEncoding = errors.New("unsupported content encoding")
+	ErrMalformedBody       = errors.New("malformed compressed request body")
+	ErrJSONTooDeep         = errors.New("request body JSON nested too deeply")
+)
+
+type limits struct {
+	maxBody         int64
+	maxDecompressed int64
+	maxRatio        float64
+	maxDepth        int
+}
+
+// Middleware reads each request body through cfg's limits and hands the
+// handler the decoded body, with Content-Encoding removed. Oversized bodies
+// and bodies whose compression ratio exceeds the limit get 413, unsupported
+// encodings 415, and JSON nested too deeply 400. Bodies are buffered, so it
+// must not wrap streaming uploads.
+func Middleware(cfg config.LimitsConfig) func(http.Handler) http.Handler {
+	l := limits{
+		maxBody:         orDefault(cfg.MaxBodyBytes, defaultMaxBodyBytes),
+		maxDecompressed: orDefault(cfg.MaxDecompressedBytes, defaultMaxDecompressedBytes),
+		maxRatio:        orDefault(cfg.MaxDecompressionRatio, defaultMaxDecompressionRatio),
+		maxDepth:        orDefault(cfg.MaxJSONDepth, defaultMaxJSONDepth),
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body, err := l.read(r)
+			if err == nil && isJSON(r) {
+				err = checkDepth(body, l.maxDepth)
+			}
+			if err != nil {
+				l.reject(w, err)
+				return
+			}
+
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			r.ContentLength = int64(len(body))
+			r.Header.Del("Content-Encoding")
+			r.Header.Del("Content-Length")
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// read returns the decoded body of r.
+func (l limits) read(r *http.Request) ([]byte, error) {
+	if r.ContentLength > l.maxBody {
+		return nil, ErrBodyTooLarge
+	}
+
+	raw := &countingReader{r: io.LimitReader(r.Body, l.maxBody+1)}
+	var (
+		src    io.Reader = raw
+		maxOut           = l.maxBody
+	)
+
+	switch enc := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding"))); enc {
+	case "", "identity":
+	case "gzip", "x-gzip":
+		zr, err := gzip.NewReader(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrMalformedBody, err)
+		}
+		src, maxOut = zr, l.maxDecompressed
+	case "deflate":
+		zr, err := zlib.NewReader(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrMalformedBody, err)
+		}
+		src, maxOut = zr, l.maxDecompressed
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedEncoding, enc)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(src, maxOut+1))
+	switch {
+	case raw.n > l.maxBody:
+		return nil, ErrBodyTooLarge
+	case err != nil && src != raw:
+		return nil, fmt.Errorf("%w: %w", ErrMalformedBody, err)
+	case err != nil:
+		return nil, err
+	case int64(len(body)) > maxOut:
+		return nil, ErrBodyTooLarge
+	}
+
+	// Small bodies may compress well; the ratio only matters once the
+	// output outgrows what an uncompressed body could be.
+	if src != raw && int64(len(body)) > l.maxBody && float64(len(body)) > l.maxRatio*float64(raw.n) {
+		return nil, ErrDecompressionRatio
+	}
+
+	return body, nil
+}
+
+func (l limits) reject(w http.ResponseWriter, err error) {
+	p := validate.Problem{Status: http.StatusBadRequest, Detail: err.Error()}
+
+	switch {
+	case errors.Is(err, ErrBodyTooLarge), errors.Is(err, ErrDecompressionRatio):
+		p.Status = http.StatusRequestEntityTooLarge
+		p.MaxBytes = l.maxBody
+	case errors.Is(err, ErrUnsupportedEncoding):
+		p.Status = http.StatusUnsupportedMediaType
+		w.Header().Set("Accept-Encoding", "gzip, deflate")
+	case errors.Is(err, ErrJSONTooDeep):
+		p.MaxDepth = l.maxDepth
+	}
+
+	validate.WriteProblem(w, p)
+}
+
+// isJSON reports whether r's body should be JSON. Handlers decode JSON
+// regardless of Content-Type, so a missing one counts.
+func isJSON(r *http.Request) bool {
+	ct := r.Header.Get("Content-Type")
+	if ct == "" {
+		return true
+	}
+	mt, _, err := mime.ParseMediaType(ct)
+	return err == nil && (mt == "application/json" || strings.HasSuffix(mt, "+json"))
+}
+
+// checkDepth rejects JSON nested deeper than max. Syntax errors are left for
+// the handler to report.
+func checkDepth(body []byte, max int) error {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	depth := 0
+
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil
+		}
+
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+			if depth > max {
+				return ErrJSONTooDeep
+			}
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+	}
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func orDefault[T int | int64 | float64](v, def T) T {
+	if v > 0 {
+		return v
+	}
+	return def
+}
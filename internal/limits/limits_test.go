@@ -0,0 +1,140 @@
+package limits
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"marketflash/internal/config"
+)
+
+func gzipped(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	zw.Close()
+	return buf.Bytes()
+}
+
+func TestMiddleware(t *testing.T) {
+	var got string
+	handler := Middleware(config.LimitsConfig{
+		MaxBodyBytes:         1024,
+		MaxDecompressedBytes: 64 * 1024,
+		MaxJSONDepth:         3,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got = string(body)
+		if r.Header.Get("Content-Encoding") != "" {
+			t.Error("expected Content-Encoding to be removed")
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	bomb := gzipped(t, bytes.Repeat([]byte{' '}, 60*1024))
+
+	tests := []struct {
+		name       string
+		body       []byte
+		encoding   string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "plain body",
+			body:       []byte(`{"symbol":"AAPL"}`),
+			wantStatus: http.StatusNoContent,
+			wantBody:   `{"symbol":"AAPL"}`,
+		},
+		{
+			name:       "gzip body is decoded",
+			body:       gzipped(t, []byte(`{"symbol":"MSFT"}`)),
+			encoding:   "gzip",
+			wantStatus: http.StatusNoContent,
+			wantBody:   `{"symbol":"MSFT"}`,
+		},
+		{
+			name:       "too large",
+			body:       bytes.Repeat([]byte{'a'}, 2048),
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:       "decompression bomb",
+			body:       bomb,
+			encoding:   "gzip",
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:       "decompresses past the limit",
+			body:       gzipped(t, bytes.Repeat([]byte{' '}, 128*1024)),
+			encoding:   "gzip",
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:       "unsupported encoding",
+			body:       []byte("x"),
+			encoding:   "br",
+			wantStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:       "malformed gzip",
+			body:       []byte("not gzip"),
+			encoding:   "gzip",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "too deep",
+			body:       []byte(`{"a":[{"b":[1]}]}`),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid JSON is left to the handler",
+			body:       []byte(`{"a":`),
+			wantStatus: http.StatusNoContent,
+			wantBody:   `{"a":`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = ""
+			req := httptest.NewRequest(http.MethodPost, "/v1/watchlists", bytes.NewReader(tt.body))
+			if tt.encoding != "" {
+				req.Header.Set("Content-Encoding", tt.encoding)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body)
+			}
+			if tt.wantBody != "" && got != tt.wantBody {
+				t.Errorf("expected handler to read %q, got %q", tt.wantBody, got)
+			}
+			if rec.Code >= 400 && rec.Header().Get("Content-Type") != "application/problem+json" {
+				t.Errorf("expected a problem+json error, got %s", rec.Body)
+			}
+		})
+	}
+}
+
+func TestMiddlewareSkipsNonJSONDepth(t *testing.T) {
+	handler := Middleware(config.LimitsConfig{MaxJSONDepth: 1})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("[[[[]]]]"))
+	req.Header.Set("Content-Type", "text/csv")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("expected non-JSON body to pass, got %d", rec.Code)
+	}
+}
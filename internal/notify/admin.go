@@ -0,0 +1,110 @@
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"marketflash/internal/auth"
+	"marketflash/internal/validate"
+)
+
+// DeadLetterHandler serves dead-letter operations under /admin/dead-letters:
+//
+//	GET    /admin/dead-letters                 list dead letters, optionally ?channel=
+//	GET    /admin/dead-letters/{id}            fetch a dead letter
+//	PATCH  /admin/dead-letters/{id}            edit channel, title or body
+//	POST   /admin/dead-letters/{id}/redeliver  redeliver, removing it on success
+//	DELETE /admin/dead-letters/{id}            discard a dead letter
+//
+// Every route requires the admin scope. It must be mounted behind
+// auth.Manager.Middleware.
+func DeadLetterHandler(d *Dispatcher) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /admin/dead-letters", func(w http.ResponseWriter, r *http.Request) {
+		letters, err := d.deadLetters.List(r.Context())
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		channel := r.URL.Query().Get("channel")
+		filtered := []DeadLetter{}
+		for _, dl := range letters {
+			if channel == "" || dl.Channel == channel {
+				filtered = append(filtered, dl)
+			}
+		}
+		writeJSON(w, http.StatusOK, filtered)
+	})
+
+	mux.HandleFunc("GET /admin/dead-letters/{id}", func(w http.ResponseWriter, r *http.Request) {
+		dl, err := d.deadLetters.Get(r.Context(), r.PathValue("id"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, dl)
+	})
+
+	mux.HandleFunc("PATCH /admin/dead-letters/{id}", func(w http.ResponseWriter, r *http.Request) {
+		var edit DeadLetterEdit
+		if err := validate.Decode(r, &edit); err != nil {
+			validate.WriteError(w, err)
+			return
+		}
+
+		dl, err := d.EditDeadLetter(r.Context(), r.PathValue("id"), edit)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, dl)
+	})
+
+	mux.HandleFunc("POST /admin/dead-letters/{id}/redeliver", func(w http.ResponseWriter, r *http.Request) {
+		err := d.Redeliver(r.Context(), r.PathValue("id"))
+		switch {
+		case err == nil:
+			w.WriteHeader(http.StatusNoContent)
+		case errors.Is(err, ErrDeadLetterNotFound), errors.Is(err, ErrUnknownChannel):
+			writeError(w, err)
+		default:
+			// The delivery itself failed; the dead letter now records why.
+			validate.WriteStatus(w, http.StatusBadGateway, err)
+		}
+	})
+
+	mux.HandleFunc("DELETE /admin/dead-letters/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if err := d.deadLetters.Delete(r.Context(), r.PathValue("id")); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	return auth.RequireScope(auth.ScopeAdmin)(mux)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrDeadLetterNotFound), errors.Is(err, ErrTemplateNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrUnknownChannel), errors.Is(err, ErrMissingTemplate):
+		status = http.StatusBadRequest
+	case errors.Is(err, ErrInvalidTemplate):
+		status = http.StatusUnprocessableEntity
+	case errors.Is(err, ErrRateLimited):
+		status = http.StatusTooManyRequests
+	}
+
+	validate.WriteStatus(w, status, err)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
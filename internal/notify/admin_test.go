@@ -0,0 +1,149 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"marketflash/internal/auth"
+	"marketflash/internal/config"
+)
+
+func TestRedeliver(t *testing.T) {
+	ctx := context.Background()
+	errDown := errors.New("connection refused")
+
+	t.Run("removes delivered dead letter", func(t *testing.T) {
+		d, dls, _ := newTestDispatcher(config.RetryConfig{MaxAttempts: 1})
+		broken := &fakeNotifier{errs: []error{errDown}}
+		working := &fakeNotifier{}
+		d.Register("ops", broken, config.RateConfig{})
+		d.Register("desk", working, config.RateConfig{})
+
+		_ = d.Dispatch(ctx, "ops", Message{ID: "m1", Title: "AAPL above 200"})
+		letters, _ := dls.List(ctx)
+		id := letters[0].ID
+
+		desk := "desk"
+		title := "AAPL above 200 (resent)"
+		dl, err := d.EditDeadLetter(ctx, id, DeadLetterEdit{Channel: &desk, Title: &title})
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if dl.Channel != "desk" || dl.Message.Title != title || dl.Message.ID != "m1" {
+			t.Errorf("unexpected edited dead letter: %+v", dl)
+		}
+
+		if err := d.Redeliver(ctx, id); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if len(working.sent) != 1 || working.sent[0].Title != title {
+			t.Errorf("expected edited message on desk, got %+v", working.sent)
+		}
+		if _, err := dls.Get(ctx, id); !errors.Is(err, ErrDeadLetterNotFound) {
+			t.Errorf("expected error %v, got: %v", ErrDeadLetterNotFound, err)
+		}
+	})
+
+	t.Run("records repeated failure", func(t *testing.T) {
+		d, dls, _ := newTestDispatcher(config.RetryConfig{MaxAttempts: 2})
+		n := &fakeNotifier{errs: []error{errDown, errDown, errDown, errDown}}
+		d.Register("ops", n, config.RateConfig{})
+
+		_ = d.Dispatch(ctx, "ops", Message{ID: "m1"})
+		letters, _ := dls.List(ctx)
+		id := letters[0].ID
+
+		if err := d.Redeliver(ctx, id); !errors.Is(err, errDown) {
+			t.Fatalf("expected error %v, got: %v", errDown, err)
+		}
+
+		letters, _ = dls.List(ctx)
+		if len(letters) != 1 || letters[0].ID != id || letters[0].Attempts != 4 {
+			t.Errorf("expected the same dead letter with 4 attempts, got %+v", letters)
+		}
+	})
+
+	t.Run("rejects unknown channel", func(t *testing.T) {
+		d, dls, _ := newTestDispatcher(config.RetryConfig{})
+		_ = dls.Add(ctx, DeadLetter{ID: "dl1", Channel: "ops"})
+
+		nope := "nope"
+		if _, err := d.EditDeadLetter(ctx, "dl1", DeadLetterEdit{Channel: &nope}); !errors.Is(err, ErrUnknownChannel) {
+			t.Errorf("expected error %v, got: %v", ErrUnknownChannel, err)
+		}
+		if err := d.Redeliver(ctx, "missing"); !errors.Is(err, ErrDeadLetterNotFound) {
+			t.Errorf("expected error %v, got: %v", ErrDeadLetterNotFound, err)
+		}
+	})
+}
+
+func TestDeadLetterHandler(t *testing.T) {
+	d, dls, _ := newTestDispatcher(config.RetryConfig{MaxAttempts: 1})
+	n := &fakeNotifier{errs: []error{errors.New("connection refused")}}
+	d.Register("ops", n, config.RateConfig{})
+
+	ctx := context.Background()
+	_ = dls.Add(ctx, DeadLetter{ID: "dl1", Channel: "ops", Message: Message{ID: "m1", Title: "t"}})
+	_ = dls.Add(ctx, DeadLetter{ID: "dl2", Channel: "desk", Message: Message{ID: "m2"}})
+
+	handler := DeadLetterHandler(d)
+
+	do := func(method, path string, scopes []auth.Scope, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req = req.WithContext(auth.WithPrincipal(req.Context(), auth.Principal{KeyID: "k1", Scopes: scopes}))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	admin := []auth.Scope{auth.ScopeAdmin}
+
+	if rec := do(http.MethodGet, "/admin/dead-letters", []auth.Scope{auth.ScopeReadQuotes}, ""); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for non-admin key, got %d", rec.Code)
+	}
+
+	rec := do(http.MethodGet, "/admin/dead-letters?channel=ops", admin, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var letters []DeadLetter
+	if err := json.NewDecoder(rec.Body).Decode(&letters); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(letters) != 1 || letters[0].ID != "dl1" {
+		t.Errorf("expected only dl1 for channel ops, got %+v", letters)
+	}
+
+	if rec := do(http.MethodGet, "/admin/dead-letters/missing", admin, ""); rec.Code != http.StatusNotFound || rec.Header().Get("Content-Type") != "application/problem+json" {
+		t.Errorf("expected a 404 problem, got %d: %s", rec.Code, rec.Body)
+	}
+
+	if rec := do(http.MethodPatch, "/admin/dead-letters/dl2", admin, `{"channel":"nope"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for unknown channel, got %d", rec.Code)
+	}
+
+	rec = do(http.MethodPatch, "/admin/dead-letters/dl2", admin, `{"channel":"ops","body":"retarget"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+
+	if rec := do(http.MethodPost, "/admin/dead-letters/dl1/redeliver", admin, ""); rec.Code != http.StatusBadGateway {
+		t.Errorf("expected 502 for failed redelivery, got %d", rec.Code)
+	}
+
+	if rec := do(http.MethodDelete, "/admin/dead-letters/dl1", admin, ""); rec.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d", rec.Code)
+	}
+
+	_ = do(http.MethodPost, "/admin/dead-letters/dl2/redeliver", admin, "")
+	if rec := do(http.MethodPost, "/admin/dead-letters/dl2/redeliver", admin, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 once redelivered, got %d", rec.Code)
+	}
+	if len(n.sent) != 1 || n.sent[0].Body != "retarget" {
+		t.Errorf("expected edited message delivered to ops, got %+v", n.sent)
+	}
+}
@@ -0,0 +1,186 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+)
+
+type capturedRequest struct {
+	path   string
+	header http.Header
+	body   []byte
+}
+
+func captureServer(t *testing.T, status int) (*httptest.Server, <-chan capturedRequest) {
+	t.Helper()
+
+	reqs := make(chan capturedRequest, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		reqs <- capturedRequest{path: r.URL.Path, header: r.Header, body: body}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, reqs
+}
+
+func TestWebhook(t *testing.T) {
+	srv, reqs := captureServer(t, http.StatusOK)
+
+	w := NewWebhook(srv.URL, "hook-secret")
+	w.now = func() time.Time { return time.Unix(1700000000, 0) }
+
+	msg := Message{ID: "m1", Title: "AAPL above 200", Body: "last 201.10"}
+	if err := w.Send(context.Background(), msg); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	req := <-reqs
+	if got := req.header.Get(TimestampHeader); got != "1700000000" {
+		t.Errorf("expected timestamp header, got %q", got)
+	}
+	want := "sha256=" + Sign([]byte("hook-secret"), "1700000000", req.body)
+	if got := req.header.Get(SignatureHeader); got != want {
+		t.Errorf("expected signature %q, got %q", want, got)
+	}
+
+	var got Message
+	if err := json.Unmarshal(req.body, &got); err != nil || got.ID != "m1" {
+		t.Errorf("expected message payload, got %s (%v)", req.body, err)
+	}
+}
+
+func TestHTTPStatusClassification(t *testing.T) {
+	tests := []struct {
+		status        int
+		wantPermanent bool
+	}{
+		{status: http.StatusBadRequest, wantPermanent: true},
+		{status: http.StatusNotFound, wantPermanent: true},
+		{status: http.StatusTooManyRequests, wantPermanent: false},
+		{status: http.StatusBadGateway, wantPermanent: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			srv, _ := captureServer(t, tt.status)
+
+			err := NewSlack(srv.URL).Send(context.Background(), Message{Body: "x"})
+			if err == nil {
+				t.Fatalf("expected error for status %d", tt.status)
+			}
+			if got := errors.Is(err, ErrPermanent); got != tt.wantPermanent {
+				t.Errorf("expected permanent=%v, got %v: %v", tt.wantPermanent, got, err)
+			}
+		})
+	}
+}
+
+func TestSlack(t *testing.T) {
+	srv, reqs := captureServer(t, http.StatusOK)
+
+	if err := NewSlack(srv.URL).Send(context.Background(), Message{Title: "BTC-USD", Body: "down 5%"}); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	var payload map[string]string
+	if err := json.Unmarshal((<-reqs).body, &payload); err != nil {
+		t.Fatalf("failed to decode payload: %v", err)
+	}
+	if payload["text"] != "*BTC-USD*\ndown 5%" {
+		t.Errorf("unexpected slack text %q", payload["text"])
+	}
+}
+
+func TestTelegram(t *testing.T) {
+	srv, reqs := captureServer(t, http.StatusOK)
+
+	tg := NewTelegram("123:abc", "42")
+	tg.baseURL = srv.URL
+
+	if err := tg.Send(context.Background(), Message{Title: "ETH-USD", Body: "up 3%"}); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	req := <-reqs
+	if req.path != "/bot123:abc/sendMessage" {
+		t.Errorf("unexpected path %q", req.path)
+	}
+
+	var payload map[string]string
+	if err := json.Unmarshal(req.body, &payload); err != nil {
+		t.Fatalf("failed to decode payload: %v", err)
+	}
+	if payload["chat_id"] != "42" || payload["text"] != "ETH-USD\n\nup 3%" {
+		t.Errorf("unexpected payload %v", payload)
+	}
+}
+
+func TestDeliveryErrorOmitsURL(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	tg := NewTelegram("123:secret-token", "42")
+	tg.baseURL = srv.URL
+
+	err := tg.Send(context.Background(), Message{Body: "x"})
+	if err == nil {
+		t.Fatal("expected error from a closed server")
+	}
+	if strings.Contains(err.Error(), "secret-token") || strings.Contains(err.Error(), srv.URL) {
+		t.Errorf("expected the URL to be stripped, got: %v", err)
+	}
+}
+
+func TestEmail(t *testing.T) {
+	e := NewEmail("smtp.example.com", 0, "user", "pass", "mf@example.com", []string{"a@example.com", "b@example.com"})
+	e.now = func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	var (
+		gotAddr string
+		gotAuth smtp.Auth
+		gotTo   []string
+		gotMsg  string
+	)
+	e.sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		gotAddr, gotAuth, gotTo, gotMsg = addr, a, to, string(msg)
+		return nil
+	}
+
+	msg := Message{ID: "m1", Title: "TSLA alert\r\nBcc: evil@example.com", Body: "line1\nline2"}
+	if err := e.Send(context.Background(), msg); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	if gotAddr != "smtp.example.com:587" {
+		t.Errorf("expected default port 587, got %q", gotAddr)
+	}
+	if gotAuth == nil {
+		t.Errorf("expected auth to be configured")
+	}
+	if len(gotTo) != 2 {
+		t.Errorf("expected two recipients, got %v", gotTo)
+	}
+	if strings.Contains(gotMsg, "\r\nBcc:") {
+		t.Errorf("expected subject line breaks to be stripped, got:\n%s", gotMsg)
+	}
+	for _, want := range []string{
+		"To: a@example.com, b@example.com\r\n",
+		"Subject: TSLA alert  Bcc: evil@example.com\r\n",
+		"Message-ID: <m1@smtp.example.com>\r\n",
+		"\r\n\r\nline1\r\nline2\r\n",
+	} {
+		if !strings.Contains(gotMsg, want) {
+			t.Errorf("expected message to contain %q, got:\n%s", want, gotMsg)
+		}
+	}
+}
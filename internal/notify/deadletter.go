@@ -0,0 +1,103 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+// DeadLetter is a notification that exhausted its delivery attempts.
+type DeadLetter struct {
+	ID       string    `json:"id"`
+	Channel  string    `json:"channel"`
+	Message  Message   `json:"message"`
+	Error    string    `json:"error"`
+	Attempts int       `json:"attempts"`
+	FailedAt time.Time `json:"failed_at"`
+}
+
+// DeadLetterStore persists failed deliveries so they can be inspected, edited
+// and redelivered instead of being lost. Get, Update and Delete return
+// ErrDeadLetterNotFound for unknown IDs.
+type DeadLetterStore interface {
+	Add(ctx context.Context, dl DeadLetter) error
+	List(ctx context.Context) ([]DeadLetter, error)
+	Get(ctx context.Context, id string) (DeadLetter, error)
+	Update(ctx context.Context, dl DeadLetter) error
+	Delete(ctx context.Context, id string) error
+}
+
+// MemoryDeadLetters is an in-process DeadLetterStore, used in tests and
+// development.
+type MemoryDeadLetters struct {
+	mu      sync.RWMutex
+	letters []DeadLetter
+}
+
+// NewMemoryDeadLetters returns an empty MemoryDeadLetters.
+func NewMemoryDeadLetters() *MemoryDeadLetters {
+	return &MemoryDeadLetters{}
+}
+
+func (s *MemoryDeadLetters) Add(_ context.Context, dl DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.letters = append(s.letters, dl)
+
+	return nil
+}
+
+func (s *MemoryDeadLetters) List(_ context.Context) ([]DeadLetter, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return slices.Clone(s.letters), nil
+}
+
+func (s *MemoryDeadLetters) Get(_ context.Context, id string) (DeadLetter, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i := s.index(id)
+	if i < 0 {
+		return DeadLetter{}, fmt.Errorf("%w: %s", ErrDeadLetterNotFound, id)
+	}
+
+	return s.letters[i], nil
+}
+
+func (s *MemoryDeadLetters) Update(_ context.Context, dl DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.index(dl.ID)
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrDeadLetterNotFound, dl.ID)
+	}
+	s.letters[i] = dl
+
+	return nil
+}
+
+func (s *MemoryDeadLetters) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.index(id)
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrDeadLetterNotFound, id)
+	}
+	s.letters = slices.Delete(s.letters, i, i+1)
+
+	return nil
+}
+
+func (s *MemoryDeadLetters) index(id string) int {
+	return slices.IndexFunc(s.letters, func(dl DeadLetter) bool { return dl.ID == id })
+}
@@ -0,0 +1,383 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+var ErrDedupeState = errors.New("unable to persist dedupe state")
+
+const (
+	defaultDedupeCapacity = 100000
+	defaultDedupeFPRate   = 1e-6
+	dedupeMagic           = "MFDD1"
+
+	// maxDedupeLog is the key log size at which Mark compacts it.
+	maxDedupeLog = 1 << 20
+)
+
+// bloom is a fixed-size Bloom filter using double hashing over FNV-1a, which
+// is stable across processes so the bits can be persisted.
+type bloom struct {
+	bits []uint64
+	m    uint64 // bit count
+	k    uint64 // hash count
+}
+
+func newBloom(m, k uint64) *bloom {
+	return &bloom{bits: make([]uint64, (m+63)/64), m: m, k: k}
+}
+
+// bloomSize returns the bit and hash counts for n keys at false positive
+// rate p.
+func bloomSize(n int, p float64) (m, k uint64) {
+	bits := math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2))
+	hashes := math.Round(bits / float64(n) * math.Ln2)
+	return uint64(max(bits, 64)), uint64(max(hashes, 1))
+}
+
+func (b *bloom) indexes(key string) func(yield func(uint64) bool) {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	h1 := h.Sum64()
+	h.Write([]byte{0})
+	h2 := h.Sum64() | 1
+
+	return func(yield func(uint64) bool) {
+		for i := range b.k {
+			if !yield((h1 + i*h2) % b.m) {
+				return
+			}
+		}
+	}
+}
+
+func (b *bloom) add(key string) {
+	for idx := range b.indexes(key) {
+		b.bits[idx/64] |= 1 << (idx % 64)
+	}
+}
+
+func (b *bloom) has(key string) bool {
+	for idx := range b.indexes(key) {
+		if b.bits[idx/64]&(1<<(idx%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Dedupe remembers delivered message keys for at least ttl using two Bloom
+// filter generations: keys go into the current one, and every ttl the
+// current generation becomes the previous and the old previous is dropped.
+// A false positive suppresses a genuinely new delivery, so the rate should be
+// kept very low.
+//
+// With a path, dedupe survives restarts and crash loops: Mark appends each
+// key to a log next to path and syncs it before returning, and Flush
+// compacts the log into a snapshot of both generations at path. The log is
+// also compacted by Mark once it reaches maxDedupeLog bytes.
+type Dedupe struct {
+	mu        sync.Mutex
+	path      string
+	ttl       time.Duration
+	current   *bloom
+	previous  *bloom
+	rotatedAt time.Time
+	now       func() time.Time
+
+	fileMu  sync.Mutex // guards log and logSize, and serializes snapshots
+	log     *os.File   // opened by the first Mark
+	logSize int64
+}
+
+// OpenDedupe returns a Dedupe, restoring state from path when it exists and
+// was written with the same filter size, then replaying the keys logged
+// since. Zero capacity or fpRate use the defaults.
+func OpenDedupe(path string, ttl time.Duration, capacity int, fpRate float64) (*Dedupe, error) {
+	return openDedupe(path, ttl, capacity, fpRate, time.Now)
+}
+
+func openDedupe(path string, ttl time.Duration, capacity int, fpRate float64, now func() time.Time) (*Dedupe, error) {
+	m, k := bloomSize(orDefault(capacity, defaultDedupeCapacity), orDefault(fpRate, defaultDedupeFPRate))
+
+	d := &Dedupe{
+		path:      path,
+		ttl:       ttl,
+		current:   newBloom(m, k),
+		previous:  newBloom(m, k),
+		rotatedAt: now(),
+		now:       now,
+	}
+
+	if path == "" {
+		return d, nil
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		d.restore(data)
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, fmt.Errorf("%w: %s", ErrDedupeState, err)
+	}
+
+	if err := d.replay(); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// Seen reports whether key was marked within the last ttl.
+func (d *Dedupe) Seen(key string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.rotate(d.now())
+
+	return d.current.has(key) || d.previous.has(key)
+}
+
+// Mark records key as delivered. With a path, the key is on disk when Mark
+// returns. On error the key is still remembered in memory, but will be
+// forgotten on restart.
+func (d *Dedupe) Mark(key string) error {
+	now := d.now()
+
+	d.mu.Lock()
+	d.rotate(now)
+	d.current.add(key)
+	d.mu.Unlock()
+
+	if d.path == "" {
+		return nil
+	}
+
+	d.fileMu.Lock()
+	defer d.fileMu.Unlock()
+
+	if err := d.appendLog(now, key); err != nil {
+		return err
+	}
+	if d.logSize >= maxDedupeLog {
+		return d.compact()
+	}
+
+	return nil
+}
+
+// Flush compacts the key log into the snapshot at path. It does nothing
+// without a path or when nothing was logged since the last compaction.
+func (d *Dedupe) Flush() error {
+	if d.path == "" {
+		return nil
+	}
+
+	d.fileMu.Lock()
+	defer d.fileMu.Unlock()
+
+	if d.logSize == 0 {
+		return nil
+	}
+
+	return d.compact()
+}
+
+func (d *Dedupe) rotate(now time.Time) {
+	elapsed := now.Sub(d.rotatedAt)
+	if elapsed < d.ttl {
+		return
+	}
+
+	fresh := newBloom(d.current.m, d.current.k)
+	if elapsed >= 2*d.ttl {
+		d.previous = newBloom(d.current.m, d.current.k)
+	} else {
+		d.previous = d.current
+	}
+	d.current = fresh
+	d.rotatedAt = now
+}
+
+// logPath is where keys marked since the last snapshot are appended.
+func (d *Dedupe) logPath() string {
+	return d.path + ".log"
+}
+
+// appendLog appends a record of key marked at now to the log and syncs it.
+// Each record is the mark time in Unix nanoseconds, the key length and the
+// key. The caller must hold d.fileMu.
+func (d *Dedupe) appendLog(now time.Time, key string) error {
+	if d.log == nil {
+		f, err := os.OpenFile(d.logPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrDedupeState, err)
+		}
+		d.log = f
+	}
+
+	var rec bytes.Buffer
+	binary.Write(&rec, binary.LittleEndian, now.UnixNano())
+	binary.Write(&rec, binary.LittleEndian, uint32(len(key)))
+	rec.WriteString(key)
+
+	n, err := d.log.Write(rec.Bytes())
+	d.logSize += int64(n)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrDedupeState, err)
+	}
+	if err := d.log.Sync(); err != nil {
+		return fmt.Errorf("%w: %s", ErrDedupeState, err)
+	}
+
+	return nil
+}
+
+// replay marks the keys in the log as of when they were logged. A record
+// cut short by a crash ends the log and is truncated away, so later appends
+// start on a record boundary.
+func (d *Dedupe) replay() error {
+	data, err := os.ReadFile(d.logPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrDedupeState, err)
+	}
+
+	r := bytes.NewReader(data)
+	var valid int64
+	for {
+		var (
+			at     int64
+			length uint32
+		)
+		if binary.Read(r, binary.LittleEndian, &at) != nil ||
+			binary.Read(r, binary.LittleEndian, &length) != nil ||
+			int64(length) > int64(r.Len()) {
+			break
+		}
+		key := make([]byte, length)
+		r.Read(key)
+
+		d.rotate(time.Unix(0, at))
+		d.current.add(string(key))
+		valid = int64(len(data) - r.Len())
+	}
+
+	if valid < int64(len(data)) {
+		if err := os.Truncate(d.logPath(), valid); err != nil {
+			return fmt.Errorf("%w: %s", ErrDedupeState, err)
+		}
+	}
+	d.logSize = valid
+
+	return nil
+}
+
+// compact writes a snapshot to path and empties the log. Keys marked while
+// it runs wait for d.fileMu to log, so each one lands in the snapshot, the
+// emptied log or both. The caller must hold d.fileMu.
+func (d *Dedupe) compact() error {
+	d.mu.Lock()
+	state := d.encode()
+	d.mu.Unlock()
+
+	if err := d.persist(state); err != nil {
+		return err
+	}
+
+	if err := os.Truncate(d.logPath(), 0); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w: %s", ErrDedupeState, err)
+	}
+	d.logSize = 0
+
+	return nil
+}
+
+// encode serializes the state; the caller must hold d.mu.
+func (d *Dedupe) encode() []byte {
+	var buf bytes.Buffer
+	buf.WriteString(dedupeMagic)
+	binary.Write(&buf, binary.LittleEndian, d.current.m)
+	binary.Write(&buf, binary.LittleEndian, d.current.k)
+	binary.Write(&buf, binary.LittleEndian, d.rotatedAt.UnixNano())
+	binary.Write(&buf, binary.LittleEndian, d.current.bits)
+	binary.Write(&buf, binary.LittleEndian, d.previous.bits)
+	return buf.Bytes()
+}
+
+// persist writes state atomically: to a temp file that is synced before it
+// is renamed over path, so a crash leaves either the old or the new state.
+func (d *Dedupe) persist(state []byte) error {
+	dir := filepath.Dir(d.path)
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(d.path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrDedupeState, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(state); err != nil {
+		tmp.Close()
+		return fmt.Errorf("%w: %s", ErrDedupeState, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("%w: %s", ErrDedupeState, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("%w: %s", ErrDedupeState, err)
+	}
+	if err := os.Rename(tmp.Name(), d.path); err != nil {
+		return fmt.Errorf("%w: %s", ErrDedupeState, err)
+	}
+
+	// Sync the directory so the rename itself survives a crash.
+	if f, err := os.Open(dir); err == nil {
+		f.Sync()
+		f.Close()
+	}
+
+	return nil
+}
+
+// restore loads persisted state. State written with a different filter size
+// or in an unknown format is ignored, starting from empty filters.
+func (d *Dedupe) restore(data []byte) {
+	r := bytes.NewReader(data)
+
+	magic := make([]byte, len(dedupeMagic))
+	if _, err := r.Read(magic); err != nil || string(magic) != dedupeMagic {
+		return
+	}
+
+	var (
+		m, k      uint64
+		rotatedAt int64
+	)
+	if binary.Read(r, binary.LittleEndian, &m) != nil ||
+		binary.Read(r, binary.LittleEndian, &k) != nil ||
+		binary.Read(r, binary.LittleEndian, &rotatedAt) != nil ||
+		m != d.current.m || k != d.current.k {
+		return
+	}
+
+	current, previous := newBloom(m, k), newBloom(m, k)
+	if binary.Read(r, binary.LittleEndian, current.bits) != nil ||
+		binary.Read(r, binary.LittleEndian, previous.bits) != nil {
+		return
+	}
+
+	d.current, d.previous = current, previous
+	d.rotatedAt = time.Unix(0, rotatedAt)
+}
@@ -0,0 +1,230 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"marketflash/internal/config"
+)
+
+func TestDedupe(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+
+	t.Run("remembers keys for at least ttl", func(t *testing.T) {
+		now := start
+		d, err := openDedupe("", time.Hour, 1000, 0, func() time.Time { return now })
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+
+		if d.Seen("ops\x00a1") {
+			t.Fatal("expected unmarked key to be unseen")
+		}
+		d.Mark("ops\x00a1")
+
+		now = start.Add(59 * time.Minute)
+		if !d.Seen("ops\x00a1") {
+			t.Error("expected key to be seen within ttl")
+		}
+
+		now = start.Add(90 * time.Minute)
+		if !d.Seen("ops\x00a1") {
+			t.Error("expected key to survive one rotation")
+		}
+
+		now = start.Add(3 * time.Hour)
+		if d.Seen("ops\x00a1") {
+			t.Error("expected key to be forgotten after two rotations")
+		}
+	})
+
+	t.Run("persists across restarts", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "dedupe.bin")
+		now := func() time.Time { return start }
+
+		d, err := openDedupe(path, time.Hour, 1000, 0, now)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if err := d.Mark("ops\x00a1"); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+
+		// The process dies before any Flush.
+		unflushed, err := openDedupe(path, time.Hour, 1000, 0, now)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if !unflushed.Seen("ops\x00a1") {
+			t.Error("expected the mark to be replayed from the log")
+		}
+
+		if err := d.Flush(); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if matches, _ := filepath.Glob(path + ".tmp*"); len(matches) != 0 {
+			t.Errorf("expected no temp files left behind, got %v", matches)
+		}
+		if info, err := os.Stat(path + ".log"); err != nil || info.Size() != 0 {
+			t.Errorf("expected Flush to empty the log, got %v (err %v)", info, err)
+		}
+
+		reopened, err := openDedupe(path, time.Hour, 1000, 0, now)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if !reopened.Seen("ops\x00a1") {
+			t.Error("expected key to be restored from disk")
+		}
+		if reopened.Seen("ops\x00b2") {
+			t.Error("expected other key to be unseen")
+		}
+
+		resized, err := openDedupe(path, time.Hour, 5000, 0, now)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if resized.Seen("ops\x00a1") {
+			t.Error("expected state with a different filter size to be discarded")
+		}
+	})
+
+	t.Run("drops a record cut short by a crash", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "dedupe.bin")
+		now := func() time.Time { return start }
+
+		d, err := openDedupe(path, time.Hour, 1000, 0, now)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if err := d.Mark("ops\x00a1"); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+
+		f, err := os.OpenFile(path+".log", os.O_WRONLY|os.O_APPEND, 0)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		f.Write([]byte{1, 2, 3})
+		f.Close()
+
+		reopened, err := openDedupe(path, time.Hour, 1000, 0, now)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if err := reopened.Mark("ops\x00b2"); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+
+		again, err := openDedupe(path, time.Hour, 1000, 0, now)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if !again.Seen("ops\x00a1") || !again.Seen("ops\x00b2") {
+			t.Error("expected both complete records to be replayed")
+		}
+	})
+
+	t.Run("ignores corrupt state", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "dedupe.bin")
+		if err := os.WriteFile(path, []byte("garbage"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		d, err := OpenDedupe(path, time.Hour, 1000, 0)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if d.Seen("ops\x00a1") {
+			t.Error("expected empty state")
+		}
+	})
+
+	t.Run("reports unwritable state", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "missing", "dedupe.bin")
+
+		d, err := OpenDedupe(path, time.Hour, 1000, 0)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if err := d.Mark("ops\x00a1"); !errors.Is(err, ErrDedupeState) {
+			t.Errorf("expected error %v, got: %v", ErrDedupeState, err)
+		}
+		if !d.Seen("ops\x00a1") {
+			t.Error("expected key to be remembered in memory")
+		}
+	})
+}
+
+func TestDispatchDedupe(t *testing.T) {
+	ctx := context.Background()
+
+	d, _, _ := newTestDispatcher(config.RetryConfig{})
+	dedupe, err := OpenDedupe("", time.Hour, 1000, 0)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	d.UseDedupe(dedupe)
+
+	ops, desk := &fakeNotifier{}, &fakeNotifier{}
+	d.Register("ops", ops, config.RateConfig{})
+	d.Register("desk", desk, config.RateConfig{})
+
+	msg := Message{ID: "rule-7@1700000000", Title: "AAPL above 200"}
+	for range 3 {
+		if err := d.Dispatch(ctx, "ops", msg); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+	}
+	if err := d.Dispatch(ctx, "desk", msg); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	if len(ops.sent) != 1 {
+		t.Errorf("expected 1 delivery to ops, got %d", len(ops.sent))
+	}
+	if len(desk.sent) != 1 {
+		t.Errorf("expected 1 delivery to desk, got %d", len(desk.sent))
+	}
+
+	for range 2 {
+		if err := d.Dispatch(ctx, "ops", Message{Title: "no id"}); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+	}
+	if len(ops.sent) != 3 {
+		t.Errorf("expected messages without an id to bypass dedupe, got %d deliveries", len(ops.sent))
+	}
+}
+
+func TestDispatchDedupeAfterCrash(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "dedupe.bin")
+	msg := Message{ID: "rule-7@1700000000", Title: "AAPL above 200"}
+
+	// Each dispatcher dies right after its delivery: no Run, no Flush.
+	var sent int
+	for range 3 {
+		d, _, _ := newTestDispatcher(config.RetryConfig{})
+		dedupe, err := OpenDedupe(path, time.Hour, 1000, 0)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		d.UseDedupe(dedupe)
+
+		ops := &fakeNotifier{}
+		d.Register("ops", ops, config.RateConfig{})
+		if err := d.Dispatch(ctx, "ops", msg); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		sent += len(ops.sent)
+	}
+
+	if sent != 1 {
+		t.Errorf("expected 1 delivery across crashed dispatchers, got %d", sent)
+	}
+}
@@ -0,0 +1,181 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"marketflash/internal/config"
+)
+
+var severityRank = map[Severity]int{SeverityInfo: 0, SeverityWarning: 1, SeverityCritical: 2}
+
+type digest struct {
+	interval   time.Duration
+	severities map[Severity]bool
+
+	mu      sync.Mutex
+	pending []heldMessage
+	since   time.Time // when the first pending message was held
+}
+
+func newDigest(cfg config.DigestConfig) *digest {
+	severities := cfg.Severities
+	if len(severities) == 0 {
+		severities = []string{string(SeverityInfo)}
+	}
+
+	dg := &digest{interval: cfg.Interval, severities: make(map[Severity]bool, len(severities))}
+	for _, severity := range severities {
+		dg.severities[Severity(severity)] = true
+	}
+
+	return dg
+}
+
+func (dg *digest) holds(severity Severity) bool {
+	if severity == "" {
+		severity = SeverityInfo
+	}
+	return dg.severities[severity]
+}
+
+func (dg *digest) add(held heldMessage, now time.Time) {
+	dg.mu.Lock()
+	defer dg.mu.Unlock()
+
+	if len(dg.pending) == 0 {
+		dg.since = now
+	}
+	dg.pending = append(dg.pending, held)
+}
+
+// take returns the pending messages once interval has passed since the first
+// was held, or straight away with force.
+func (dg *digest) take(now time.Time, force bool) []heldMessage {
+	dg.mu.Lock()
+	defer dg.mu.Unlock()
+
+	if len(dg.pending) == 0 || (!force && now.Sub(dg.since) < dg.interval) {
+		return nil
+	}
+
+	msgs := dg.pending
+	dg.pending = nil
+	return msgs
+}
+
+// SetDigest applies cfg to the channel name; see config.DigestConfig. It must
+// not be called once the dispatcher is in use.
+func (d *Dispatcher) SetDigest(name string, cfg config.DigestConfig) error {
+	ch, ok := d.channels[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownChannel, name)
+	}
+
+	ch.digest = nil
+	if cfg.Enabled() {
+		ch.digest = newDigest(cfg)
+	}
+
+	d.channels[name] = ch
+	return nil
+}
+
+// FlushDigests sends a digest on every channel whose digest interval has
+// passed. Digests that fail are dead-lettered as usual; their errors are
+// returned joined.
+func (d *Dispatcher) FlushDigests(ctx context.Context) error {
+	var errs []error
+
+	for _, name := range slices.Sorted(maps.Keys(d.channels)) {
+		ch := d.channels[name]
+		if ch.digest == nil {
+			continue
+		}
+
+		held := ch.digest.take(d.now(), false)
+		if len(held) == 0 {
+			continue
+		}
+
+		if err := d.send(ctx, name, ch, digestOf(held, d.now().UTC())); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// deadLetterDigests dead-letters every pending digest with
+// ErrHeldAtShutdown instead of sending it before its interval is up.
+func (d *Dispatcher) deadLetterDigests(ctx context.Context) error {
+	var errs []error
+
+	for _, name := range slices.Sorted(maps.Keys(d.channels)) {
+		ch := d.channels[name]
+		if ch.digest == nil {
+			continue
+		}
+
+		held := ch.digest.take(d.now(), true)
+		if len(held) == 0 {
+			continue
+		}
+
+		reason := fmt.Errorf("%w: digest pending on %s", ErrHeldAtShutdown, name)
+		if err := d.deadLetter(ctx, name, digestOf(held, d.now().UTC()).msg, 0, reason); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// digestOf combines held into one message carrying the highest of their
+// severities. Their dedupe keys are marked once the digest is delivered, so a
+// message lost with an undelivered digest is not suppressed when replayed.
+func digestOf(held []heldMessage, now time.Time) heldMessage {
+	var (
+		body       strings.Builder
+		severity   = SeverityInfo
+		dedupeKeys []string
+	)
+
+	for i, h := range held {
+		msg := h.msg
+		dedupeKeys = append(dedupeKeys, h.dedupeKeys...)
+
+		if severityRank[msg.Severity] > severityRank[severity] {
+			severity = msg.Severity
+		}
+
+		if i > 0 {
+			body.WriteString("\n")
+		}
+		fmt.Fprintf(&body, "- %s %s", msg.CreatedAt.Format("15:04"), msg.Title)
+		if msg.Body != "" {
+			fmt.Fprintf(&body, ": %s", msg.Body)
+		}
+	}
+
+	title := fmt.Sprintf("Digest: %d notifications", len(held))
+	if len(held) == 1 {
+		title = "Digest: 1 notification"
+	}
+
+	msg := Message{
+		ID:        newID(),
+		Severity:  severity,
+		Title:     title,
+		Body:      body.String(),
+		CreatedAt: now,
+	}
+
+	return heldMessage{msg: msg, dedupeKeys: dedupeKeys}
+}
@@ -0,0 +1,170 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"marketflash/internal/config"
+)
+
+func TestDispatchDigest(t *testing.T) {
+	ctx := context.Background()
+	d, _, _ := newTestDispatcher(config.RetryConfig{MaxAttempts: 1})
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+
+	n := &fakeNotifier{}
+	d.Register("email", n, config.RateConfig{})
+	err := d.SetDigest("email", config.DigestConfig{Interval: time.Hour, Severities: []string{"info", "warning"}})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	for _, msg := range []Message{
+		{Title: "AAPL crossed 200"},
+		{Severity: SeverityWarning, Title: "Feed lagging", Body: "3s behind"},
+		{ID: "critical", Severity: SeverityCritical, Title: "Margin call"},
+	} {
+		if err := d.Dispatch(ctx, "email", msg); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		now = now.Add(10 * time.Minute)
+	}
+
+	if len(n.sent) != 1 || n.sent[0].ID != "critical" {
+		t.Fatalf("expected only the critical message to be sent at once, got %+v", n.sent)
+	}
+
+	// The interval runs from the first held message, at 09:00.
+	if err := d.FlushDigests(ctx); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(n.sent) != 1 {
+		t.Fatalf("expected the digest to wait for its interval, got %+v", n.sent)
+	}
+
+	now = time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	if err := d.FlushDigests(ctx); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(n.sent) != 2 {
+		t.Fatalf("expected a digest, got %+v", n.sent)
+	}
+
+	got := n.sent[1]
+	want := Message{
+		Severity: SeverityWarning,
+		Title:    "Digest: 2 notifications",
+		Body:     "- 09:00 AAPL crossed 200\n- 09:10 Feed lagging: 3s behind",
+	}
+	if got.Severity != want.Severity || got.Title != want.Title || got.Body != want.Body {
+		t.Errorf("expected digest %+v, got %+v", want, got)
+	}
+
+	if err := d.FlushDigests(ctx); err != nil || len(n.sent) != 2 {
+		t.Errorf("expected an empty digest not to be sent, got %+v (err %v)", n.sent, err)
+	}
+
+	if err := d.SetDigest("missing", config.DigestConfig{}); !errors.Is(err, ErrUnknownChannel) {
+		t.Errorf("expected error %v, got: %v", ErrUnknownChannel, err)
+	}
+}
+
+func TestDigestDuringQuietHours(t *testing.T) {
+	ctx := context.Background()
+	d, _, _ := newTestDispatcher(config.RetryConfig{MaxAttempts: 1})
+	now := time.Date(2024, 3, 1, 23, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+
+	n := &fakeNotifier{}
+	d.Register("email", n, config.RateConfig{})
+	if err := d.SetDigest("email", config.DigestConfig{Interval: time.Hour}); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if err := d.SetQuietHours("email", config.QuietHoursConfig{Start: "22:00", End: "07:00"}); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	if err := d.Dispatch(ctx, "email", Message{Title: "AAPL crossed 200"}); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	now = now.Add(time.Hour)
+	if err := d.FlushDigests(ctx); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(n.sent) != 0 {
+		t.Fatalf("expected the digest to be queued for quiet hours, got %+v", n.sent)
+	}
+
+	now = time.Date(2024, 3, 2, 7, 0, 0, 0, time.UTC)
+	if err := d.FlushQueued(ctx); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(n.sent) != 1 || n.sent[0].Title != "Digest: 1 notification" {
+		t.Errorf("expected the digest after quiet hours, got %+v", n.sent)
+	}
+}
+
+func TestDigestDedupeAndShutdown(t *testing.T) {
+	ctx := context.Background()
+	d, deadLetters, _ := newTestDispatcher(config.RetryConfig{MaxAttempts: 1})
+	now := time.Date(2024, 3, 1, 23, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+
+	dedupe, err := OpenDedupe("", time.Hour, 1000, 0)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	d.UseDedupe(dedupe)
+
+	email, pager := &fakeNotifier{}, &fakeNotifier{}
+	d.Register("email", email, config.RateConfig{})
+	d.Register("pager", pager, config.RateConfig{})
+	if err := d.SetDigest("email", config.DigestConfig{Interval: time.Hour}); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if err := d.SetQuietHours("pager", config.QuietHoursConfig{Start: "22:00", End: "07:00"}); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	if err := d.Dispatch(ctx, "email", Message{ID: "aapl-200", Title: "AAPL crossed 200"}); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if err := d.Dispatch(ctx, "pager", Message{ID: "feed", Title: "Feed lagging"}); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if dedupe.Seen("email\x00aapl-200") {
+		t.Fatal("expected a held message not to be marked delivered")
+	}
+
+	// Run returns straight away on a cancelled context, dead-lettering
+	// everything still held rather than delivering it early.
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := d.Run(cancelled); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	if len(email.sent) != 0 || len(pager.sent) != 0 {
+		t.Errorf("expected nothing delivered on shutdown, got %+v and %+v", email.sent, pager.sent)
+	}
+
+	dls, _ := deadLetters.List(ctx)
+	held := make(map[string]DeadLetter)
+	for _, dl := range dls {
+		if !strings.Contains(dl.Error, ErrHeldAtShutdown.Error()) {
+			t.Errorf("expected dead letter held at shutdown, got: %s", dl.Error)
+		}
+		held[dl.Channel] = dl
+	}
+	if held["email"].Message.Title != "Digest: 1 notification" || held["pager"].Message.ID != "feed" || len(dls) != 2 {
+		t.Errorf("expected the pending digest and queued message dead-lettered, got %+v", dls)
+	}
+	if dedupe.Seen("email\x00aapl-200") || dedupe.Seen("pager\x00feed") {
+		t.Error("expected undelivered messages not to be marked")
+	}
+}
@@ -0,0 +1,434 @@
+package notify
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"marketflash/internal/config"
+	"marketflash/internal/ratelimit"
+)
+
+const (
+	defaultMaxAttempts    = 5
+	defaultInitialBackoff = time.Second
+	defaultMaxBackoff     = time.Minute
+)
+
+type channel struct {
+	notifier Notifier
+	limiter  *ratelimit.Bucket // nil when unlimited
+	health   *channelHealth
+	quiet    *quietHours // nil without quiet hours
+	digest   *digest     // nil without a digest
+	owners   []string    // API key IDs allowed to test-send
+}
+
+// Dispatcher delivers messages to named channels, rate limiting each channel,
+// retrying transient failures with exponential backoff, and dead-lettering
+// messages that still fail. Delivery outcomes feed per-channel statistics and
+// failure-rate alerts; see WatchHealth. With dedupe enabled, a message
+// already delivered to a channel is not delivered there again.
+type Dispatcher struct {
+	channels       map[string]channel
+	deadLetters    DeadLetterStore
+	dedupe         *Dedupe // nil when disabled
+	routes         *routes
+	health         config.HealthConfig
+	healthAlerts   chan Message // sent by Run; see observe
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	now            func() time.Time
+	sleep          func(ctx context.Context, d time.Duration) error
+}
+
+// NewDispatcher returns a Dispatcher with no channels. Zero retry values use
+// the defaults of 5 attempts backing off from 1s to at most 1m.
+func NewDispatcher(retry config.RetryConfig, deadLetters DeadLetterStore) *Dispatcher {
+	d := &Dispatcher{
+		channels:       make(map[string]channel),
+		deadLetters:    deadLetters,
+		healthAlerts:   make(chan Message, maxHealthAlerts),
+		maxAttempts:    orDefault(retry.MaxAttempts, defaultMaxAttempts),
+		initialBackoff: orDefault(retry.InitialBackoff, defaultInitialBackoff),
+		maxBackoff:     orDefault(retry.MaxBackoff, defaultMaxBackoff),
+		now:            time.Now,
+		sleep:          sleep,
+	}
+	d.WatchHealth(config.HealthConfig{})
+	_ = d.SetRouting(nil)
+
+	return d
+}
+
+// New builds a Dispatcher with a notifier for every channel in cfg.
+func New(cfg config.NotificationsConfig, deadLetters DeadLetterStore) (*Dispatcher, error) {
+	d := NewDispatcher(cfg.Retry, deadLetters)
+	d.WatchHealth(cfg.Health)
+
+	if cfg.Dedupe.TTL > 0 {
+		dedupe, err := OpenDedupe(cfg.Dedupe.Path, cfg.Dedupe.TTL, cfg.Dedupe.Capacity, cfg.Dedupe.FalsePositiveRate)
+		if err != nil {
+			return nil, err
+		}
+		d.UseDedupe(dedupe)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(cfg.Channels)) {
+		ch := cfg.Channels[name]
+
+		var n Notifier
+		switch ch.Type {
+		case "webhook":
+			n = NewWebhook(ch.URL, ch.Secret)
+		case "slack":
+			n = NewSlack(ch.URL)
+		case "email":
+			n = NewEmail(ch.SMTPHost, ch.SMTPPort, ch.Username, ch.Password, ch.From, ch.To)
+		case "telegram":
+			n = NewTelegram(ch.BotToken, ch.ChatID)
+		default:
+			return nil, fmt.Errorf("%w: %s has type %q", ErrUnknownChannel, name, ch.Type)
+		}
+
+		d.Register(name, n, ch.RateLimit)
+		if err := d.SetQuietHours(name, ch.QuietHours); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if err := d.SetDigest(name, ch.Digest); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if err := d.SetOwners(name, ch.Owners); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	if err := d.SetRouting(cfg.Routing); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// Register adds or replaces the channel name. It must not be called once the
+// dispatcher is in use.
+func (d *Dispatcher) Register(name string, n Notifier, limit config.RateConfig) {
+	ch := channel{notifier: n, health: &channelHealth{}}
+	if limit.Enabled() {
+		ch.limiter = ratelimit.NewBucket(limit.Rate(), limit.Burst)
+	}
+	d.channels[name] = ch
+}
+
+// UseDedupe enables delivery dedupe. It must not be called once the
+// dispatcher is in use.
+func (d *Dispatcher) UseDedupe(dedupe *Dedupe) {
+	d.dedupe = dedupe
+}
+
+// Dispatch delivers msg to the named channel, blocking through rate limiting
+// and retries; run it from a worker rather than the alert evaluation loop.
+// A message that cannot be delivered is dead-lettered and the delivery error
+// is returned. A message without an ID is assigned one.
+//
+// With dedupe enabled, a message whose caller-supplied ID was already
+// delivered to the channel is skipped and nil returned, so producers should
+// derive IDs deterministically from what triggered the message.
+//
+// During the channel's quiet hours, msg may instead be queued for Run to
+// deliver later, or dropped, per its severity; nil is returned for both. On a
+// channel with a digest, msg may likewise be held for Run to send in the next
+// digest.
+func (d *Dispatcher) Dispatch(ctx context.Context, channelName string, msg Message) error {
+	ch, ok := d.channels[channelName]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownChannel, channelName)
+	}
+
+	held := heldMessage{msg: msg}
+	if held.msg.ID == "" {
+		held.msg.ID = newID()
+	} else if d.dedupe != nil {
+		key := channelName + "\x00" + msg.ID
+		if d.dedupe.Seen(key) {
+			return nil
+		}
+		held.dedupeKeys = []string{key}
+	}
+	if held.msg.CreatedAt.IsZero() {
+		held.msg.CreatedAt = d.now().UTC()
+	}
+
+	if ch.digest != nil && ch.digest.holds(msg.Severity) {
+		ch.digest.add(held, d.now())
+		return nil
+	}
+
+	return d.send(ctx, channelName, ch, held)
+}
+
+// heldMessage is a message with the dedupe keys to mark once it is
+// delivered. A digest carries the keys of every message it combines.
+type heldMessage struct {
+	msg        Message
+	dedupeKeys []string
+}
+
+// send delivers held to ch, subject to its quiet hours; see deliverHeld.
+func (d *Dispatcher) send(ctx context.Context, channelName string, ch channel, held heldMessage) error {
+	if held, err := d.holdForQuietHours(ctx, channelName, ch, held); held {
+		return err
+	}
+	return d.deliverHeld(ctx, channelName, ch, held)
+}
+
+// flushDedupe compacts the dedupe key log. Marks are already durable, and
+// the next flush tries again, so a failure is not reported.
+func (d *Dispatcher) flushDedupe() {
+	if d.dedupe != nil {
+		_ = d.dedupe.Flush()
+	}
+}
+
+// deliverHeld delivers held to ch, marking its dedupe keys once delivered and
+// dead-lettering it on failure.
+func (d *Dispatcher) deliverHeld(ctx context.Context, channelName string, ch channel, held heldMessage) error {
+	msg := held.msg
+
+	start := d.now()
+	attempts, err := d.deliver(ctx, ch, msg)
+	d.observe(channelName, ch, d.now().Sub(start), err != nil)
+
+	if err == nil {
+		for _, key := range held.dedupeKeys {
+			// The message was delivered, so a persistence failure must not
+			// surface as a delivery error and trigger a resend; the key is
+			// still remembered until restart.
+			_ = d.dedupe.Mark(key)
+		}
+		return nil
+	}
+
+	if dlErr := d.deadLetter(ctx, channelName, msg, attempts, err); dlErr != nil {
+		return errors.Join(fmt.Errorf("delivering to %s: %w", channelName, err), dlErr)
+	}
+
+	return fmt.Errorf("delivering to %s after %d attempts: %w", channelName, attempts, err)
+}
+
+// deadLetter stores msg as undeliverable to channelName because of err.
+func (d *Dispatcher) deadLetter(ctx context.Context, channelName string, msg Message, attempts int, err error) error {
+	dl := DeadLetter{
+		ID:       newID(),
+		Channel:  channelName,
+		Message:  msg,
+		Error:    err.Error(),
+		Attempts: attempts,
+		FailedAt: d.now().UTC(),
+	}
+
+	// Dead-lettering must survive the caller's cancellation, or a shutdown
+	// mid-retry would silently drop the message.
+	if err := d.deadLetters.Add(context.WithoutCancel(ctx), dl); err != nil {
+		return fmt.Errorf("dead-lettering: %w", err)
+	}
+
+	return nil
+}
+
+// SetOwners sets the API key IDs that own the channel name; see Owns. It must
+// not be called once the dispatcher is in use.
+func (d *Dispatcher) SetOwners(name string, keyIDs []string) error {
+	ch, ok := d.channels[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownChannel, name)
+	}
+
+	ch.owners = slices.Clone(keyIDs)
+	d.channels[name] = ch
+	return nil
+}
+
+// Owns reports whether the API key keyID owns the channel name, and so may
+// send test messages to it.
+func (d *Dispatcher) Owns(name, keyID string) bool {
+	ch, ok := d.channels[name]
+	return ok && keyID != "" && slices.Contains(ch.owners, keyID)
+}
+
+// SendOnce delivers msg to the named channel with a single attempt, skipping
+// dedupe, health tracking and dead-lettering. It is meant for interactive
+// test sends, where the caller wants the error straight away, so rather than
+// waiting for the channel's rate limit it fails with ErrRateLimited.
+func (d *Dispatcher) SendOnce(ctx context.Context, channelName string, msg Message) error {
+	ch, ok := d.channels[channelName]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownChannel, channelName)
+	}
+
+	if ch.limiter != nil {
+		if ok, wait := ch.limiter.Allow(); !ok {
+			return fmt.Errorf("%w: retry in %s", ErrRateLimited, wait.Round(time.Second))
+		}
+	}
+
+	if msg.ID == "" {
+		msg.ID = newID()
+	}
+	if msg.CreatedAt.IsZero() {
+		msg.CreatedAt = d.now().UTC()
+	}
+
+	return ch.notifier.Send(ctx, msg)
+}
+
+// DeadLetterEdit changes a dead letter before redelivery. Nil fields are left
+// unchanged.
+type DeadLetterEdit struct {
+	Channel *string `json:"channel"`
+	Title   *string `json:"title"`
+	Body    *string `json:"body"`
+}
+
+// EditDeadLetter applies edit to the dead letter id, e.g. to point it at a
+// working channel, and returns the result.
+func (d *Dispatcher) EditDeadLetter(ctx context.Context, id string, edit DeadLetterEdit) (DeadLetter, error) {
+	dl, err := d.deadLetters.Get(ctx, id)
+	if err != nil {
+		return DeadLetter{}, err
+	}
+
+	if edit.Channel != nil {
+		if _, ok := d.channels[*edit.Channel]; !ok {
+			return DeadLetter{}, fmt.Errorf("%w: %s", ErrUnknownChannel, *edit.Channel)
+		}
+		dl.Channel = *edit.Channel
+	}
+	if edit.Title != nil {
+		dl.Message.Title = *edit.Title
+	}
+	if edit.Body != nil {
+		dl.Message.Body = *edit.Body
+	}
+
+	if err := d.deadLetters.Update(ctx, dl); err != nil {
+		return DeadLetter{}, err
+	}
+
+	return dl, nil
+}
+
+// Redeliver retries the dead letter id on its channel with the usual retry
+// policy. It is removed once delivered; otherwise its error, attempt count and
+// failure time are updated and the delivery error is returned.
+func (d *Dispatcher) Redeliver(ctx context.Context, id string) error {
+	dl, err := d.deadLetters.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	ch, ok := d.channels[dl.Channel]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownChannel, dl.Channel)
+	}
+
+	start := d.now()
+	attempts, err := d.deliver(ctx, ch, dl.Message)
+	d.observe(dl.Channel, ch, d.now().Sub(start), err != nil)
+
+	if err == nil {
+		if d.dedupe != nil {
+			_ = d.dedupe.Mark(dl.Channel + "\x00" + dl.Message.ID)
+		}
+		return d.deadLetters.Delete(context.WithoutCancel(ctx), id)
+	}
+
+	dl.Error = err.Error()
+	dl.Attempts += attempts
+	dl.FailedAt = d.now().UTC()
+	if uerr := d.deadLetters.Update(context.WithoutCancel(ctx), dl); uerr != nil {
+		return errors.Join(fmt.Errorf("redelivering to %s: %w", dl.Channel, err), fmt.Errorf("updating dead letter: %w", uerr))
+	}
+
+	return fmt.Errorf("redelivering to %s after %d attempts: %w", dl.Channel, attempts, err)
+}
+
+// deliver sends msg with rate limiting and retries, returning the number of
+// attempts made and the last error.
+func (d *Dispatcher) deliver(ctx context.Context, ch channel, msg Message) (int, error) {
+	var (
+		err      error
+		attempts int
+	)
+
+	for attempts < d.maxAttempts {
+		if attempts > 0 {
+			if serr := d.sleep(ctx, d.backoff(attempts)); serr != nil {
+				break
+			}
+		}
+
+		if ch.limiter != nil {
+			if lerr := ch.limiter.Wait(ctx); lerr != nil {
+				break
+			}
+		}
+
+		attempts++
+		err = ch.notifier.Send(ctx, msg)
+		if err == nil || errors.Is(err, ErrPermanent) {
+			break
+		}
+	}
+
+	if err == nil && attempts == 0 {
+		err = ctx.Err()
+	}
+
+	return attempts, err
+}
+
+// backoff returns the delay before retry number attempt (1-based).
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	delay := d.initialBackoff
+	for range attempt - 1 {
+		delay *= 2
+		if delay >= d.maxBackoff {
+			return d.maxBackoff
+		}
+	}
+	return min(delay, d.maxBackoff)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// orDefault returns v, or def when v is the zero value.
+func orDefault[T comparable](v, def T) T {
+	var zero T
+	if v == zero {
+		return def
+	}
+	return v
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,181 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"marketflash/internal/config"
+)
+
+// fakeNotifier fails with the queued errors in order, then succeeds.
+type fakeNotifier struct {
+	mu    sync.Mutex
+	errs  []error
+	sent  []Message
+	calls int
+}
+
+func (f *fakeNotifier) Send(_ context.Context, msg Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls++
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		return err
+	}
+	f.sent = append(f.sent, msg)
+	return nil
+}
+
+func newTestDispatcher(retry config.RetryConfig) (*Dispatcher, *MemoryDeadLetters, *[]time.Duration) {
+	dls := NewMemoryDeadLetters()
+	d := NewDispatcher(retry, dls)
+	d.now = func() time.Time { return time.Unix(1700000000, 0) }
+
+	var sleeps []time.Duration
+	d.sleep = func(ctx context.Context, dur time.Duration) error {
+		sleeps = append(sleeps, dur)
+		return ctx.Err()
+	}
+
+	return d, dls, &sleeps
+}
+
+func TestDispatch(t *testing.T) {
+	ctx := context.Background()
+	errDown := errors.New("connection refused")
+
+	t.Run("retries transient failures with backoff", func(t *testing.T) {
+		d, dls, sleeps := newTestDispatcher(config.RetryConfig{})
+		n := &fakeNotifier{errs: []error{errDown, errDown}}
+		d.Register("ops", n, config.RateConfig{})
+
+		if err := d.Dispatch(ctx, "ops", Message{Title: "AAPL above 200"}); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+
+		if n.calls != 3 {
+			t.Errorf("expected 3 attempts, got %d", n.calls)
+		}
+		if want := []time.Duration{time.Second, 2 * time.Second}; !reflect.DeepEqual(*sleeps, want) {
+			t.Errorf("expected backoff %v, got %v", want, *sleeps)
+		}
+		if n.sent[0].ID == "" || n.sent[0].CreatedAt.IsZero() {
+			t.Errorf("expected id and timestamp to be assigned, got %+v", n.sent[0])
+		}
+		if letters, _ := dls.List(ctx); len(letters) != 0 {
+			t.Errorf("expected no dead letters, got %v", letters)
+		}
+	})
+
+	t.Run("dead-letters after max attempts", func(t *testing.T) {
+		d, dls, sleeps := newTestDispatcher(config.RetryConfig{MaxAttempts: 3, InitialBackoff: 10 * time.Second, MaxBackoff: 15 * time.Second})
+		n := &fakeNotifier{errs: []error{errDown, errDown, errDown}}
+		d.Register("ops", n, config.RateConfig{})
+
+		err := d.Dispatch(ctx, "ops", Message{ID: "m1", Title: "t"})
+		if !errors.Is(err, errDown) {
+			t.Fatalf("expected error %v, got: %v", errDown, err)
+		}
+		if want := []time.Duration{10 * time.Second, 15 * time.Second}; !reflect.DeepEqual(*sleeps, want) {
+			t.Errorf("expected capped backoff %v, got %v", want, *sleeps)
+		}
+
+		letters, _ := dls.List(ctx)
+		if len(letters) != 1 {
+			t.Fatalf("expected one dead letter, got %d", len(letters))
+		}
+		dl := letters[0]
+		if dl.Channel != "ops" || dl.Message.ID != "m1" || dl.Attempts != 3 || dl.Error != errDown.Error() {
+			t.Errorf("unexpected dead letter: %+v", dl)
+		}
+	})
+
+	t.Run("permanent failure is not retried", func(t *testing.T) {
+		d, dls, _ := newTestDispatcher(config.RetryConfig{})
+		n := &fakeNotifier{errs: []error{fmt.Errorf("%w: bad payload", ErrPermanent)}}
+		d.Register("ops", n, config.RateConfig{})
+
+		if err := d.Dispatch(ctx, "ops", Message{}); !errors.Is(err, ErrPermanent) {
+			t.Fatalf("expected error %v, got: %v", ErrPermanent, err)
+		}
+		if n.calls != 1 {
+			t.Errorf("expected a single attempt, got %d", n.calls)
+		}
+		if letters, _ := dls.List(ctx); len(letters) != 1 {
+			t.Errorf("expected one dead letter, got %d", len(letters))
+		}
+	})
+
+	t.Run("cancellation dead-letters pending message", func(t *testing.T) {
+		d, dls, _ := newTestDispatcher(config.RetryConfig{})
+		n := &fakeNotifier{errs: []error{errDown}}
+		d.Register("ops", n, config.RateConfig{})
+
+		cctx, cancel := context.WithCancel(ctx)
+		d.sleep = func(context.Context, time.Duration) error {
+			cancel()
+			return context.Canceled
+		}
+
+		if err := d.Dispatch(cctx, "ops", Message{}); !errors.Is(err, errDown) {
+			t.Fatalf("expected error %v, got: %v", errDown, err)
+		}
+		if letters, _ := dls.List(ctx); len(letters) != 1 || letters[0].Attempts != 1 {
+			t.Errorf("expected one dead letter after one attempt, got %+v", letters)
+		}
+	})
+
+	t.Run("unknown channel", func(t *testing.T) {
+		d, _, _ := newTestDispatcher(config.RetryConfig{})
+
+		if err := d.Dispatch(ctx, "nope", Message{}); !errors.Is(err, ErrUnknownChannel) {
+			t.Errorf("expected error %v, got: %v", ErrUnknownChannel, err)
+		}
+	})
+}
+
+func TestNew(t *testing.T) {
+	cfg := config.NotificationsConfig{
+		Channels: map[string]config.ChannelConfig{
+			"hook":  {Type: "webhook", URL: "https://example.com/hook", Secret: "s"},
+			"slack": {Type: "slack", URL: "https://hooks.slack.com/x", RateLimit: config.RateConfig{Requests: 1, Per: time.Second}},
+			"mail":  {Type: "email", SMTPHost: "smtp.example.com", From: "a@example.com", To: []string{"b@example.com"}},
+			"tg":    {Type: "telegram", BotToken: "123:abc", ChatID: "42"},
+		},
+	}
+
+	d, err := New(cfg, NewMemoryDeadLetters())
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	wantTypes := map[string]string{
+		"hook":  "*notify.Webhook",
+		"slack": "*notify.Slack",
+		"mail":  "*notify.Email",
+		"tg":    "*notify.Telegram",
+	}
+	for name, want := range wantTypes {
+		if got := fmt.Sprintf("%T", d.channels[name].notifier); got != want {
+			t.Errorf("expected %s to be %s, got %s", name, want, got)
+		}
+	}
+	if d.channels["slack"].limiter == nil || d.channels["hook"].limiter != nil {
+		t.Errorf("expected only slack to be rate limited")
+	}
+
+	_, err = New(config.NotificationsConfig{
+		Channels: map[string]config.ChannelConfig{"x": {Type: "pager"}},
+	}, NewMemoryDeadLetters())
+	if !errors.Is(err, ErrUnknownChannel) {
+		t.Errorf("expected error %v, got: %v", ErrUnknownChannel, err)
+	}
+}
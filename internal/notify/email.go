@@ -0,0 +1,84 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Email sends messages as plain-text mail over SMTP.
+type Email struct {
+	addr     string
+	host     string
+	auth     smtp.Auth
+	from     string
+	to       []string
+	now      func() time.Time
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewEmail returns a notifier sending through host:port. Authentication is
+// only used when username is set; port 0 means 587.
+func NewEmail(host string, port int, username, password, from string, to []string) *Email {
+	if port == 0 {
+		port = 587
+	}
+
+	e := &Email{
+		addr:     net.JoinHostPort(host, strconv.Itoa(port)),
+		host:     host,
+		from:     from,
+		to:       to,
+		now:      time.Now,
+		sendMail: smtp.SendMail,
+	}
+
+	if username != "" {
+		e.auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return e
+}
+
+// Send delivers msg. net/smtp has no context support, so cancellation is only
+// observed before the send starts.
+func (e *Email) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := e.sendMail(e.addr, e.auth, e.from, e.to, e.render(msg)); err != nil {
+		return fmt.Errorf("sending mail via %s: %w", e.addr, err)
+	}
+
+	return nil
+}
+
+func (e *Email) render(msg Message) []byte {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "From: %s\r\n", e.from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(e.to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", headerSafe(msg.Title))
+	fmt.Fprintf(&b, "Date: %s\r\n", e.now().Format(time.RFC1123Z))
+	if msg.ID != "" {
+		fmt.Fprintf(&b, "Message-ID: <%s@%s>\r\n", headerSafe(msg.ID), e.host)
+	}
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	b.WriteString("\r\n")
+
+	return b.Bytes()
+}
+
+// headerSafe strips line breaks so message fields cannot inject headers.
+func headerSafe(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
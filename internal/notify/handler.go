@@ -0,0 +1,162 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"marketflash/internal/auth"
+	"marketflash/internal/validate"
+)
+
+type templateRequest struct {
+	Name  string `json:"name" validate:"required,max=100"`
+	Title string `json:"title" validate:"max=1000"`
+	Body  string `json:"body" validate:"max=10000"`
+}
+
+type testSendRequest struct {
+	Channel string `json:"channel" validate:"required"`
+}
+
+// TemplateHandler serves notification templates under /v1/templates for the
+// authenticated API key:
+//
+//	GET    /v1/templates                 list the caller's templates
+//	POST   /v1/templates                 create a template
+//	PUT    /v1/templates/{id}            replace a template
+//	DELETE /v1/templates/{id}            delete a template
+//	POST   /v1/templates/lint            lint a template without saving it
+//	POST   /v1/templates/{id}/test-send  render with sample data and send once
+//	                                     to a channel the caller owns
+//
+// Templates are linted on save and rejected with 422 when broken. It must be
+// mounted behind auth.Manager.Middleware.
+func TemplateHandler(store TemplateStore, d *Dispatcher) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /v1/templates", func(w http.ResponseWriter, r *http.Request) {
+		templates, err := store.List(r.Context(), owner(r))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if templates == nil {
+			templates = []Template{}
+		}
+		writeJSON(w, http.StatusOK, templates)
+	})
+
+	save := func(w http.ResponseWriter, r *http.Request, id string, status int) {
+		var req templateRequest
+		if err := validate.Decode(r, &req); err != nil {
+			validate.WriteError(w, err)
+			return
+		}
+
+		if id != "" {
+			if _, err := store.Get(r.Context(), owner(r), id); err != nil {
+				writeError(w, err)
+				return
+			}
+		} else {
+			id = newID()
+		}
+
+		t := Template{
+			ID:        id,
+			Owner:     owner(r),
+			Name:      req.Name,
+			Title:     req.Title,
+			Body:      req.Body,
+			UpdatedAt: d.now().UTC(),
+		}
+		if err := t.Lint(); err != nil {
+			writeError(w, err)
+			return
+		}
+		if err := store.Save(r.Context(), t); err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, status, t)
+	}
+
+	mux.HandleFunc("POST /v1/templates", func(w http.ResponseWriter, r *http.Request) {
+		save(w, r, "", http.StatusCreated)
+	})
+
+	mux.HandleFunc("PUT /v1/templates/{id}", func(w http.ResponseWriter, r *http.Request) {
+		save(w, r, r.PathValue("id"), http.StatusOK)
+	})
+
+	mux.HandleFunc("DELETE /v1/templates/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if err := store.Delete(r.Context(), owner(r), r.PathValue("id")); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	mux.HandleFunc("POST /v1/templates/lint", func(w http.ResponseWriter, r *http.Request) {
+		var req templateRequest
+		if err := validate.Decode(r, &req); err != nil {
+			validate.WriteError(w, err)
+			return
+		}
+
+		t := Template{Name: req.Name, Title: req.Title, Body: req.Body}
+		if err := t.Lint(); err != nil {
+			writeError(w, err)
+			return
+		}
+
+		msg, _ := t.Render(SampleAlert)
+		writeJSON(w, http.StatusOK, msg)
+	})
+
+	mux.HandleFunc("POST /v1/templates/{id}/test-send", func(w http.ResponseWriter, r *http.Request) {
+		var req testSendRequest
+		if err := validate.Decode(r, &req); err != nil {
+			validate.WriteError(w, err)
+			return
+		}
+
+		t, err := store.Get(r.Context(), owner(r), r.PathValue("id"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		msg, err := t.Render(SampleAlert)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		msg.Title = "[test] " + msg.Title
+
+		// Channels the caller does not own are reported as unknown, so keys
+		// cannot probe for them.
+		if !d.Owns(req.Channel, owner(r)) {
+			writeError(w, fmt.Errorf("%w: %s", ErrUnknownChannel, req.Channel))
+			return
+		}
+
+		err = d.SendOnce(r.Context(), req.Channel, msg)
+		switch {
+		case err == nil:
+			writeJSON(w, http.StatusOK, msg)
+		case errors.Is(err, ErrRateLimited):
+			writeError(w, err)
+		default:
+			validate.WriteStatus(w, http.StatusBadGateway, err)
+		}
+	})
+
+	return auth.RequireScope(auth.ScopeManageAlerts)(mux)
+}
+
+func owner(r *http.Request) string {
+	p, _ := auth.PrincipalFrom(r.Context())
+	return p.KeyID
+}
@@ -0,0 +1,107 @@
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"marketflash/internal/auth"
+	"marketflash/internal/config"
+)
+
+func TestTemplateHandler(t *testing.T) {
+	d, dls, _ := newTestDispatcher(config.RetryConfig{})
+	ops := &fakeNotifier{}
+	d.Register("ops", ops, config.RateConfig{})
+	d.Register("down", &fakeNotifier{errs: []error{errors.New("connection refused")}}, config.RateConfig{})
+	health := &fakeNotifier{}
+	d.Register("health", health, config.RateConfig{})
+	d.Register("limited", &fakeNotifier{}, config.RateConfig{Requests: 1, Per: time.Hour, Burst: 1})
+	for _, name := range []string{"ops", "down", "limited"} {
+		if err := d.SetOwners(name, []string{"k1"}); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+	}
+
+	handler := TemplateHandler(NewMemoryTemplates(), d)
+
+	do := func(method, path, keyID string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req = req.WithContext(auth.WithPrincipal(req.Context(), auth.Principal{KeyID: keyID, Scopes: []auth.Scope{auth.ScopeManageAlerts}}))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do(http.MethodPost, "/v1/templates", "k1", `{"name":"bad","title":"{{.Symbl}}"}`); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for unknown field, got %d", rec.Code)
+	}
+
+	rec := do(http.MethodPost, "/v1/templates/lint", "k1", `{"name":"preview","title":"{{.Symbol}} {{.Condition}}"}`)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "AAPL above") {
+		t.Errorf("expected rendered preview, got %d: %s", rec.Code, rec.Body)
+	}
+
+	rec = do(http.MethodPost, "/v1/templates", "k1", `{"name":"price","title":"{{.Symbol}} {{.Condition}} {{.Threshold}}","body":"Last {{.Price}}"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body)
+	}
+	var created Template
+	if err := json.NewDecoder(rec.Body).Decode(&created); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if rec := do(http.MethodPut, "/v1/templates/"+created.ID, "k2", `{"name":"steal","title":"x"}`); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for another key's template, got %d", rec.Code)
+	}
+
+	if rec := do(http.MethodPut, "/v1/templates/"+created.ID, "k1", `{"name":"price","title":"{{if}}"}`); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for parse error, got %d", rec.Code)
+	}
+
+	rec = do(http.MethodPost, "/v1/templates/"+created.ID+"/test-send", "k1", `{"channel":"ops"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	if len(ops.sent) != 1 || ops.sent[0].Title != "[test] AAPL above 200" || ops.sent[0].Body != "Last 201.37" {
+		t.Errorf("unexpected test message: %+v", ops.sent)
+	}
+
+	if rec := do(http.MethodPost, "/v1/templates/"+created.ID+"/test-send", "k1", `{"channel":"nope"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for unknown channel, got %d", rec.Code)
+	}
+
+	if rec := do(http.MethodPost, "/v1/templates/"+created.ID+"/test-send", "k1", `{"channel":"health"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a channel the caller does not own, got %d", rec.Code)
+	}
+	if len(health.sent) != 0 {
+		t.Errorf("expected no send to a channel the caller does not own, got %+v", health.sent)
+	}
+
+	if rec := do(http.MethodPost, "/v1/templates/"+created.ID+"/test-send", "k1", `{"channel":"limited"}`); rec.Code != http.StatusOK {
+		t.Errorf("expected 200 within the rate limit, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/v1/templates/"+created.ID+"/test-send", "k1", `{"channel":"limited"}`); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("expected 429 past the rate limit, got %d", rec.Code)
+	}
+
+	if rec := do(http.MethodPost, "/v1/templates/"+created.ID+"/test-send", "k1", `{"channel":"down"}`); rec.Code != http.StatusBadGateway {
+		t.Errorf("expected 502 for failed send, got %d", rec.Code)
+	}
+	if letters, _ := dls.List(t.Context()); len(letters) != 0 {
+		t.Errorf("expected test sends not to be dead-lettered, got %+v", letters)
+	}
+
+	rec = do(http.MethodGet, "/v1/templates", "k2", "")
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != "[]" {
+		t.Errorf("expected empty list for another key, got %d: %s", rec.Code, rec.Body)
+	}
+
+	if rec := do(http.MethodDelete, "/v1/templates/"+created.ID, "k1", ""); rec.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,216 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"marketflash/internal/config"
+)
+
+const (
+	defaultHealthWindow     = 15 * time.Minute
+	defaultFailureThreshold = 0.5
+	defaultMinDeliveries    = 5
+
+	// maxHealthAlerts caps the alerts waiting for Run to send them. Each is
+	// about a different breach, so more than this means Run is not running.
+	maxHealthAlerts = 16
+)
+
+// ChannelStats summarises deliveries to one channel. Delivered, Failed and
+// Latency count every finished delivery since start; FailureRate covers only
+// the health window.
+type ChannelStats struct {
+	Delivered   uint64        `json:"delivered"`
+	Failed      uint64        `json:"failed"`
+	Latency     time.Duration `json:"latency"` // total time spent delivering, including retries
+	FailureRate float64       `json:"failure_rate"`
+}
+
+type outcome struct {
+	at     time.Time
+	failed bool
+}
+
+// channelHealth tracks delivery outcomes for one channel.
+type channelHealth struct {
+	mu       sync.Mutex
+	stats    ChannelStats
+	recent   []outcome // outcomes within the window, oldest first
+	alerting bool      // a breach was reported and the rate has not recovered
+}
+
+// record adds an outcome and reports whether it pushed the channel into a
+// new breach of the failure threshold.
+func (h *channelHealth) record(now time.Time, latency time.Duration, failed bool, cfg config.HealthConfig) (breached bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if failed {
+		h.stats.Failed++
+	} else {
+		h.stats.Delivered++
+	}
+	h.stats.Latency += latency
+
+	h.recent = append(h.recent, outcome{at: now, failed: failed})
+	h.prune(now, cfg.Window)
+
+	if len(h.recent) < cfg.MinDeliveries || h.stats.FailureRate < cfg.FailureThreshold {
+		h.alerting = false
+		return false
+	}
+
+	breached = !h.alerting
+	h.alerting = true
+
+	return breached
+}
+
+func (h *channelHealth) prune(now time.Time, window time.Duration) {
+	cutoff := now.Add(-window)
+	i := 0
+	for i < len(h.recent) && !h.recent[i].at.After(cutoff) {
+		i++
+	}
+	h.recent = h.recent[i:]
+
+	var failed int
+	for _, o := range h.recent {
+		if o.failed {
+			failed++
+		}
+	}
+	h.stats.FailureRate = 0
+	if len(h.recent) > 0 {
+		h.stats.FailureRate = float64(failed) / float64(len(h.recent))
+	}
+}
+
+func (h *channelHealth) snapshot(now time.Time, window time.Duration) ChannelStats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.prune(now, window)
+
+	return h.stats
+}
+
+// WatchHealth sets how channel failure rates are measured and where breaches
+// are reported. Zero values use the defaults of a 15m window, a 0.5 failure
+// threshold and 5 deliveries. It must not be called once the dispatcher is in
+// use.
+func (d *Dispatcher) WatchHealth(cfg config.HealthConfig) {
+	d.health = config.HealthConfig{
+		Window:           orDefault(cfg.Window, defaultHealthWindow),
+		FailureThreshold: orDefault(cfg.FailureThreshold, defaultFailureThreshold),
+		MinDeliveries:    orDefault(cfg.MinDeliveries, defaultMinDeliveries),
+		AlertChannel:     cfg.AlertChannel,
+	}
+}
+
+// Stats returns delivery statistics for every channel.
+func (d *Dispatcher) Stats() map[string]ChannelStats {
+	now := d.now()
+	stats := make(map[string]ChannelStats, len(d.channels))
+	for name, ch := range d.channels {
+		stats[name] = ch.health.snapshot(now, d.health.Window)
+	}
+	return stats
+}
+
+// observe records a finished delivery and alerts the health channel when it
+// tips channelName over the failure threshold. The alert is left for Run to
+// send, so the delivery that tipped the channel does not wait on its retries,
+// and is dropped when maxHealthAlerts are already waiting. A failing alert
+// channel never alerts about itself, which would only add to its backlog.
+func (d *Dispatcher) observe(channelName string, ch channel, latency time.Duration, failed bool) {
+	if !ch.health.record(d.now(), latency, failed, d.health) {
+		return
+	}
+
+	alertChannel := d.health.AlertChannel
+	if alertChannel == "" || alertChannel == channelName {
+		return
+	}
+
+	stats := ch.health.snapshot(d.now(), d.health.Window)
+	// Critical, so the alert channel's quiet hours and digest do not hold
+	// it back by default.
+	select {
+	case d.healthAlerts <- Message{
+		Severity: SeverityCritical,
+		Title:    fmt.Sprintf("Notification channel %s is failing", channelName),
+		Body: fmt.Sprintf("%.0f%% of deliveries to %s failed in the last %s. Failed messages are in the dead-letter queue.",
+			stats.FailureRate*100, channelName, d.health.Window),
+	}:
+	default:
+	}
+}
+
+// deadLetterHealthAlerts dead-letters the health alerts Run has not sent
+// yet with ErrHeldAtShutdown.
+func (d *Dispatcher) deadLetterHealthAlerts(ctx context.Context) error {
+	var errs []error
+	for {
+		select {
+		case msg := <-d.healthAlerts:
+			if err := d.deadLetterHealthAlert(ctx, msg); err != nil {
+				errs = append(errs, err)
+			}
+		default:
+			return errors.Join(errs...)
+		}
+	}
+}
+
+func (d *Dispatcher) deadLetterHealthAlert(ctx context.Context, msg Message) error {
+	reason := fmt.Errorf("%w: health alert pending", ErrHeldAtShutdown)
+	return d.deadLetter(ctx, d.health.AlertChannel, msg, 0, reason)
+}
+
+// MetricsHandler serves per-channel delivery metrics in the Prometheus text
+// exposition format.
+func MetricsHandler(d *Dispatcher) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		_ = writeMetrics(w, d.Stats())
+	})
+}
+
+func writeMetrics(w io.Writer, stats map[string]ChannelStats) error {
+	names := slices.Sorted(maps.Keys(stats))
+
+	var b []byte
+	b = append(b, "# HELP marketflash_notification_deliveries_total Finished notification deliveries by channel and result.\n"...)
+	b = append(b, "# TYPE marketflash_notification_deliveries_total counter\n"...)
+	for _, name := range names {
+		b = fmt.Appendf(b, "marketflash_notification_deliveries_total{channel=%q,result=\"delivered\"} %d\n", name, stats[name].Delivered)
+		b = fmt.Appendf(b, "marketflash_notification_deliveries_total{channel=%q,result=\"failed\"} %d\n", name, stats[name].Failed)
+	}
+
+	b = append(b, "# HELP marketflash_notification_delivery_seconds Time spent delivering notifications, including retries.\n"...)
+	b = append(b, "# TYPE marketflash_notification_delivery_seconds summary\n"...)
+	for _, name := range names {
+		s := stats[name]
+		b = fmt.Appendf(b, "marketflash_notification_delivery_seconds_sum{channel=%q} %s\n", name, strconv.FormatFloat(s.Latency.Seconds(), 'g', -1, 64))
+		b = fmt.Appendf(b, "marketflash_notification_delivery_seconds_count{channel=%q} %d\n", name, s.Delivered+s.Failed)
+	}
+
+	b = append(b, "# HELP marketflash_notification_failure_ratio Share of deliveries that failed within the health window.\n"...)
+	b = append(b, "# TYPE marketflash_notification_failure_ratio gauge\n"...)
+	for _, name := range names {
+		b = fmt.Appendf(b, "marketflash_notification_failure_ratio{channel=%q} %s\n", name, strconv.FormatFloat(stats[name].FailureRate, 'g', -1, 64))
+	}
+
+	_, err := w.Write(b)
+	return err
+}
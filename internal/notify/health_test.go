@@ -0,0 +1,165 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"marketflash/internal/config"
+)
+
+func TestHealth(t *testing.T) {
+	ctx := context.Background()
+	errDown := errors.New("connection refused")
+
+	d, _, _ := newTestDispatcher(config.RetryConfig{MaxAttempts: 1})
+	now := time.Unix(1700000000, 0)
+	d.now = func() time.Time { return now }
+	d.WatchHealth(config.HealthConfig{Window: time.Hour, FailureThreshold: 0.5, MinDeliveries: 4, AlertChannel: "pager"})
+
+	slack := &fakeNotifier{}
+	pager := &fakeNotifier{}
+	d.Register("slack", slack, config.RateConfig{})
+	d.Register("pager", pager, config.RateConfig{})
+
+	// Health alerts are critical, so the pager's quiet hours and digest do
+	// not hold them back.
+	if err := d.SetQuietHours("pager", config.QuietHoursConfig{Start: "22:00", End: "07:00"}); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if err := d.SetDigest("pager", config.DigestConfig{Interval: time.Hour}); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	send := func(fail bool) {
+		if fail {
+			slack.errs = append(slack.errs, errDown)
+		}
+		_ = d.Dispatch(ctx, "slack", Message{Title: "t"})
+		sendHealthAlerts(d)
+	}
+
+	send(false)
+	send(true)
+	send(true)
+	if len(pager.sent) != 0 {
+		t.Fatalf("expected no alert below min deliveries, got %+v", pager.sent)
+	}
+
+	send(false)
+	if len(pager.sent) != 1 || !strings.Contains(pager.sent[0].Title, "slack") || pager.sent[0].Severity != SeverityCritical {
+		t.Fatalf("expected one critical alert about slack, got %+v", pager.sent)
+	}
+
+	send(true)
+	if len(pager.sent) != 1 {
+		t.Errorf("expected a single alert per breach, got %d", len(pager.sent))
+	}
+
+	stats := d.Stats()["slack"]
+	if stats.Delivered != 2 || stats.Failed != 3 || stats.FailureRate != 0.6 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	// Old outcomes leave the window, the rate recovers and a new breach
+	// alerts again.
+	now = now.Add(2 * time.Hour)
+	for range 4 {
+		send(false)
+	}
+	if got := d.Stats()["slack"].FailureRate; got != 0 {
+		t.Errorf("expected recovered failure rate, got %v", got)
+	}
+	for range 4 {
+		send(true)
+	}
+	if len(pager.sent) != 2 {
+		t.Errorf("expected a second alert after recovery, got %d", len(pager.sent))
+	}
+}
+
+// sendHealthAlerts sends the health alerts waiting for Run, as Run would.
+func sendHealthAlerts(d *Dispatcher) {
+	for {
+		select {
+		case msg := <-d.healthAlerts:
+			_ = d.Dispatch(context.Background(), d.health.AlertChannel, msg)
+		default:
+			return
+		}
+	}
+}
+
+func TestHealthAlertsWaitForRun(t *testing.T) {
+	ctx := context.Background()
+	d, deadLetters, _ := newTestDispatcher(config.RetryConfig{MaxAttempts: 1})
+	d.WatchHealth(config.HealthConfig{MinDeliveries: 1, AlertChannel: "pager"})
+
+	pager := &fakeNotifier{}
+	d.Register("slack", &fakeNotifier{errs: []error{errors.New("down")}}, config.RateConfig{})
+	d.Register("pager", pager, config.RateConfig{})
+
+	_ = d.Dispatch(ctx, "slack", Message{Title: "t"})
+	if len(pager.sent) != 0 || len(d.healthAlerts) != 1 {
+		t.Fatalf("expected the alert to wait for Run, got %d sent and %d waiting", len(pager.sent), len(d.healthAlerts))
+	}
+
+	// Alerts Run never got to are dead-lettered on the way out.
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := d.Run(cancelled); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	dls, _ := deadLetters.List(ctx)
+	var held int
+	for _, dl := range dls {
+		if dl.Channel == "pager" && strings.Contains(dl.Error, ErrHeldAtShutdown.Error()) {
+			held++
+		}
+	}
+	if held != 1 || len(pager.sent) != 0 {
+		t.Errorf("expected the pending alert dead-lettered, got %+v", dls)
+	}
+}
+
+func TestHealthAlertChannelDoesNotAlertItself(t *testing.T) {
+	d, _, _ := newTestDispatcher(config.RetryConfig{MaxAttempts: 1})
+	d.WatchHealth(config.HealthConfig{MinDeliveries: 1, AlertChannel: "pager"})
+
+	pager := &fakeNotifier{errs: []error{errors.New("down"), errors.New("down")}}
+	d.Register("pager", pager, config.RateConfig{})
+
+	_ = d.Dispatch(context.Background(), "pager", Message{})
+	_ = d.Dispatch(context.Background(), "pager", Message{})
+
+	if pager.calls != 2 {
+		t.Errorf("expected only the two dispatched messages, got %d calls", pager.calls)
+	}
+}
+
+func TestMetricsHandler(t *testing.T) {
+	d, _, _ := newTestDispatcher(config.RetryConfig{MaxAttempts: 1})
+	d.Register("ops", &fakeNotifier{errs: []error{errors.New("down")}}, config.RateConfig{})
+	_ = d.Dispatch(context.Background(), "ops", Message{})
+	_ = d.Dispatch(context.Background(), "ops", Message{})
+
+	rec := httptest.NewRecorder()
+	MetricsHandler(d).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body := rec.Body.String()
+	for _, want := range []string{
+		`marketflash_notification_deliveries_total{channel="ops",result="delivered"} 1`,
+		`marketflash_notification_deliveries_total{channel="ops",result="failed"} 1`,
+		`marketflash_notification_delivery_seconds_count{channel="ops"} 2`,
+		`marketflash_notification_failure_ratio{channel="ops"} 0.5`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected metrics to contain %q, got:\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+var (
+	// ErrPermanent marks delivery failures that retrying cannot fix, such as
+	// a rejected payload or revoked credentials.
+	ErrPermanent      = errors.New("permanent delivery failure")
+	ErrUnknownChannel = errors.New("unknown notification channel")
+	ErrRateLimited    = errors.New("notification channel rate limit exceeded")
+)
+
+// Message is a notification ready for delivery.
+type Message struct {
+	// ID identifies the notification across retries and redeliveries.
+	ID string `json:"id"`
+	// Key identifies the alert that raised the notification, so repeats of
+	// it can be throttled; see Dispatcher.Notify.
+	Key       string    `json:"key,omitempty"`
+	Severity  Severity  `json:"severity,omitempty"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Notifier delivers messages over one channel.
+type Notifier interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+const httpTimeout = 10 * time.Second
+
+var defaultClient = &http.Client{Timeout: httpTimeout}
+
+// postJSON posts the JSON body to url. 4xx responses other than 429 are
+// permanent failures; everything else that is not 2xx may be retried.
+func postJSON(ctx context.Context, client *http.Client, url string, header http.Header, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrPermanent, stripURL(err))
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return stripURL(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("unexpected status %d: %s", resp.StatusCode, bytes.TrimSpace(detail))
+
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+		return fmt.Errorf("%w: %w", ErrPermanent, err)
+	}
+
+	return err
+}
+
+// stripURL drops the request URL from a *url.Error. Notifier URLs carry
+// secrets, such as webhook paths and bot tokens, and delivery errors end up in
+// dead letters served by the admin API.
+func stripURL(err error) error {
+	var uerr *url.Error
+	if errors.As(err, &uerr) {
+		return fmt.Errorf("%s: %w", uerr.Op, uerr.Err)
+	}
+	return err
+}
+
+func mustJSON(v any) []byte {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("notify: encoding %T: %v", v, err))
+	}
+	return b
+}
@@ -0,0 +1,246 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"marketflash/internal/config"
+)
+
+// flushInterval is how often Run checks for quiet hours that ended and
+// digests that are due.
+const flushInterval = time.Minute
+
+// maxQueued caps the messages a channel queues during quiet hours, so a noisy
+// night cannot exhaust memory. Messages beyond it are dead-lettered.
+const maxQueued = 1000
+
+// Severity ranks a message for quiet-hours policies. An empty severity is
+// treated as info.
+type Severity string
+
+var (
+	ErrQuietQueueFull = errors.New("quiet hours queue is full")
+	ErrHeldAtShutdown = errors.New("held at shutdown")
+)
+
+const (
+	SeverityInfo     Severity = "info"
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+)
+
+const (
+	quietQueue   = "queue"
+	quietDrop    = "drop"
+	quietDeliver = "deliver"
+)
+
+type quietHours struct {
+	start, end time.Duration // clock time, from 00:00
+	loc        *time.Location
+	policy     map[Severity]string
+
+	mu     sync.Mutex
+	queued []heldMessage
+}
+
+func newQuietHours(cfg config.QuietHoursConfig) (*quietHours, error) {
+	start, end, loc, err := cfg.Bounds()
+	if err != nil {
+		return nil, err
+	}
+
+	q := &quietHours{start: start, end: end, loc: loc, policy: make(map[Severity]string)}
+	for severity, policy := range cfg.Policy {
+		q.policy[Severity(severity)] = policy
+	}
+
+	return q, nil
+}
+
+// active reports whether t falls within the quiet hours. The wall clock is
+// compared rather than the time elapsed since midnight, which is an hour off
+// on DST transition days.
+func (q *quietHours) active(t time.Time) bool {
+	t = t.In(q.loc)
+	clock := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+
+	if q.start < q.end {
+		return clock >= q.start && clock < q.end
+	}
+	return clock >= q.start || clock < q.end
+}
+
+func (q *quietHours) action(severity Severity) string {
+	if severity == "" {
+		severity = SeverityInfo
+	}
+	if policy, ok := q.policy[severity]; ok {
+		return policy
+	}
+	if severity == SeverityCritical {
+		return quietDeliver
+	}
+	return quietQueue
+}
+
+// enqueue queues held, reporting false when the queue is full.
+func (q *quietHours) enqueue(held heldMessage) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.queued) >= maxQueued {
+		return false
+	}
+	q.queued = append(q.queued, held)
+	return true
+}
+
+func (q *quietHours) take() []heldMessage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	msgs := q.queued
+	q.queued = nil
+	return msgs
+}
+
+// SetQuietHours applies cfg to the channel name; see config.QuietHoursConfig.
+// It must not be called once the dispatcher is in use.
+func (d *Dispatcher) SetQuietHours(name string, cfg config.QuietHoursConfig) error {
+	ch, ok := d.channels[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownChannel, name)
+	}
+
+	if !cfg.Enabled() {
+		ch.quiet = nil
+	} else {
+		q, err := newQuietHours(cfg)
+		if err != nil {
+			return err
+		}
+		ch.quiet = q
+	}
+
+	d.channels[name] = ch
+	return nil
+}
+
+// holdForQuietHours reports whether held was queued or dropped because the
+// channel is in quiet hours. A message that would overflow the queue is
+// dead-lettered instead, and the error says why.
+func (d *Dispatcher) holdForQuietHours(ctx context.Context, channelName string, ch channel, held heldMessage) (bool, error) {
+	if ch.quiet == nil || !ch.quiet.active(d.now()) {
+		return false, nil
+	}
+
+	switch ch.quiet.action(held.msg.Severity) {
+	case quietQueue:
+		if ch.quiet.enqueue(held) {
+			return true, nil
+		}
+		err := fmt.Errorf("%w: %d messages held for %s", ErrQuietQueueFull, maxQueued, channelName)
+		if dlErr := d.deadLetter(ctx, channelName, held.msg, 0, err); dlErr != nil {
+			return true, errors.Join(err, dlErr)
+		}
+		return true, err
+	case quietDrop:
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// FlushQueued dispatches the messages queued on every channel whose quiet
+// hours have ended. Messages that fail are dead-lettered as usual; their
+// errors are returned joined.
+func (d *Dispatcher) FlushQueued(ctx context.Context) error {
+	var errs []error
+
+	for _, name := range slices.Sorted(maps.Keys(d.channels)) {
+		ch := d.channels[name]
+		if ch.quiet == nil || ch.quiet.active(d.now()) {
+			continue
+		}
+
+		for _, held := range ch.quiet.take() {
+			// Delivered directly: a queued digest must not be digested
+			// again.
+			if err := d.deliverHeld(ctx, name, ch, held); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// deadLetterQueued dead-letters every message still queued for quiet hours
+// with ErrHeldAtShutdown, so it can be redelivered once the quiet hours it
+// was held for are over.
+func (d *Dispatcher) deadLetterQueued(ctx context.Context) error {
+	var errs []error
+
+	for _, name := range slices.Sorted(maps.Keys(d.channels)) {
+		ch := d.channels[name]
+		if ch.quiet == nil {
+			continue
+		}
+
+		for _, held := range ch.quiet.take() {
+			reason := fmt.Errorf("%w: queued for quiet hours on %s", ErrHeldAtShutdown, name)
+			if err := d.deadLetter(ctx, name, held.msg, 0, reason); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Run flushes messages queued during quiet hours once they end, sends
+// digests as they come due, and sends health alerts, until ctx is cancelled;
+// wrap it with app.NewBackground. Quiet hours and digests are checked every
+// minute. Held messages are kept in
+// memory, so when ctx is cancelled Run dead-letters every pending digest,
+// queued message and health alert rather than lose them or deliver them early; see
+// ErrHeldAtShutdown. Nothing is delivered on the way out, so stopping does
+// not wait on notifiers. The dedupe key log is
+// compacted on the same schedule and once more on the way out.
+func (d *Dispatcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// Dead-lettering outlives ctx; see deadLetter.
+			_ = d.deadLetterDigests(ctx)
+			_ = d.deadLetterQueued(ctx)
+			_ = d.deadLetterHealthAlerts(ctx)
+			d.flushDedupe()
+			return nil
+		case msg := <-d.healthAlerts:
+			// select picks at random when ctx is also done; an alert
+			// taken then is held like the rest.
+			if ctx.Err() != nil {
+				_ = d.deadLetterHealthAlert(ctx, msg)
+				continue
+			}
+			// Failed deliveries are already dead-lettered.
+			_ = d.Dispatch(ctx, d.health.AlertChannel, msg)
+		case <-ticker.C:
+			// Failed deliveries are already dead-lettered.
+			_ = d.FlushQueued(ctx)
+			_ = d.FlushDigests(ctx)
+			d.flushDedupe()
+		}
+	}
+}
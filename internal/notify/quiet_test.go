@@ -0,0 +1,137 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"marketflash/internal/config"
+)
+
+func TestQuietHoursActive(t *testing.T) {
+	helsinki, err := time.LoadLocation("Europe/Helsinki")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+
+	overnight, err := newQuietHours(config.QuietHoursConfig{Start: "22:00", End: "07:00", Timezone: "Europe/Helsinki"})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	lunch, err := newQuietHours(config.QuietHoursConfig{Start: "12:00", End: "13:00"})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		q    *quietHours
+		at   time.Time
+		want bool
+	}{
+		{name: "late evening", q: overnight, at: time.Date(2024, 3, 1, 23, 30, 0, 0, helsinki), want: true},
+		{name: "early morning", q: overnight, at: time.Date(2024, 3, 1, 6, 59, 0, 0, helsinki), want: true},
+		{name: "morning", q: overnight, at: time.Date(2024, 3, 1, 7, 0, 0, 0, helsinki), want: false},
+		// Clocks went forward at 03:00 that night, so only 6.5 hours have
+		// passed since midnight.
+		{name: "morning after DST change", q: overnight, at: time.Date(2024, 3, 31, 7, 30, 0, 0, helsinki), want: false},
+		{name: "other timezone", q: overnight, at: time.Date(2024, 3, 1, 21, 0, 0, 0, time.UTC), want: true},
+		{name: "within daytime window", q: lunch, at: time.Date(2024, 3, 1, 12, 15, 0, 0, time.UTC), want: true},
+		{name: "after daytime window", q: lunch, at: time.Date(2024, 3, 1, 13, 0, 0, 0, time.UTC), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.q.active(tt.at); got != tt.want {
+				t.Errorf("expected active %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDispatchQuietHours(t *testing.T) {
+	ctx := context.Background()
+	d, dls, _ := newTestDispatcher(config.RetryConfig{MaxAttempts: 1})
+	now := time.Date(2024, 3, 1, 23, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+
+	n := &fakeNotifier{}
+	d.Register("pager", n, config.RateConfig{})
+	err := d.SetQuietHours("pager", config.QuietHoursConfig{
+		Start:  "22:00",
+		End:    "07:00",
+		Policy: map[string]string{"warning": "drop"},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	for _, msg := range []Message{
+		{ID: "info", Title: "AAPL crossed 200"},
+		{ID: "warning", Severity: SeverityWarning, Title: "Feed lagging"},
+		{ID: "critical", Severity: SeverityCritical, Title: "Margin call"},
+	} {
+		if err := d.Dispatch(ctx, "pager", msg); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+	}
+
+	if len(n.sent) != 1 || n.sent[0].ID != "critical" {
+		t.Fatalf("expected only the critical message during quiet hours, got %+v", n.sent)
+	}
+
+	// Still quiet, so nothing is flushed.
+	if err := d.FlushQueued(ctx); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(n.sent) != 1 {
+		t.Fatalf("expected queue to be held, got %+v", n.sent)
+	}
+
+	now = time.Date(2024, 3, 2, 7, 1, 0, 0, time.UTC)
+	if err := d.FlushQueued(ctx); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(n.sent) != 2 || n.sent[1].ID != "info" {
+		t.Errorf("expected the queued info message after quiet hours, got %+v", n.sent)
+	}
+
+	if dead, _ := dls.List(ctx); len(dead) != 0 {
+		t.Errorf("expected no dead letters, got %+v", dead)
+	}
+
+	if err := d.SetQuietHours("missing", config.QuietHoursConfig{}); !errors.Is(err, ErrUnknownChannel) {
+		t.Errorf("expected error %v, got: %v", ErrUnknownChannel, err)
+	}
+}
+
+func TestQuietHoursQueueLimit(t *testing.T) {
+	ctx := context.Background()
+	d, dls, _ := newTestDispatcher(config.RetryConfig{MaxAttempts: 1})
+	d.now = func() time.Time { return time.Date(2024, 3, 1, 23, 0, 0, 0, time.UTC) }
+
+	n := &fakeNotifier{}
+	d.Register("pager", n, config.RateConfig{})
+	if err := d.SetQuietHours("pager", config.QuietHoursConfig{Start: "22:00", End: "07:00"}); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	for range maxQueued {
+		if err := d.Dispatch(ctx, "pager", Message{Title: "AAPL crossed 200"}); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+	}
+
+	err := d.Dispatch(ctx, "pager", Message{ID: "overflow", Title: "AAPL crossed 201"})
+	if !errors.Is(err, ErrQuietQueueFull) {
+		t.Fatalf("expected error %v, got: %v", ErrQuietQueueFull, err)
+	}
+
+	dead, _ := dls.List(ctx)
+	if len(dead) != 1 || dead[0].Message.ID != "overflow" {
+		t.Errorf("expected the overflowing message to be dead-lettered, got %+v", dead)
+	}
+	if len(n.sent) != 0 {
+		t.Errorf("expected nothing sent during quiet hours, got %d", len(n.sent))
+	}
+}
@@ -0,0 +1,141 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"marketflash/internal/config"
+)
+
+var ErrNoRoute = errors.New("no notification route for severity")
+
+// routeSweepInterval is how often claim forgets alerts whose repeat interval
+// has passed.
+const routeSweepInterval = time.Minute
+
+type severityRoute struct {
+	channels []string
+	repeat   time.Duration
+}
+
+type alertKey struct {
+	severity Severity
+	key      string
+}
+
+// routes holds the severity routing and when each alert was last sent.
+type routes struct {
+	bySeverity map[Severity]severityRoute
+
+	mu        sync.Mutex
+	lastSent  map[alertKey]time.Time
+	lastSweep time.Time
+}
+
+// SetRouting replaces the severity routing used by Notify; see
+// config.SeverityRoute. It must not be called once the dispatcher is in use.
+func (d *Dispatcher) SetRouting(routing map[string]config.SeverityRoute) error {
+	r := &routes{
+		bySeverity: make(map[Severity]severityRoute, len(routing)),
+		lastSent:   make(map[alertKey]time.Time),
+	}
+
+	for _, severity := range slices.Sorted(maps.Keys(routing)) {
+		route := routing[severity]
+		for _, name := range route.Channels {
+			if _, ok := d.channels[name]; !ok {
+				return fmt.Errorf("%w: %s", ErrUnknownChannel, name)
+			}
+		}
+		r.bySeverity[Severity(severity)] = severityRoute{
+			channels: slices.Clone(route.Channels),
+			repeat:   route.RepeatInterval,
+		}
+	}
+
+	d.routes = r
+	return nil
+}
+
+// Notify dispatches an alert to every channel routed for its severity. A
+// repeat of the same alert, identified by msg.Key, within the route's repeat
+// interval is skipped. An alert that no channel accepted does not count as
+// sent, so a retry is not suppressed. Delivery errors from each channel are
+// returned joined; see Dispatch.
+func (d *Dispatcher) Notify(ctx context.Context, msg Message) error {
+	severity := msg.Severity
+	if severity == "" {
+		severity = SeverityInfo
+	}
+
+	route, ok := d.routes.bySeverity[severity]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNoRoute, severity)
+	}
+
+	now := d.now()
+	if !d.routes.claim(severity, msg.Key, route.repeat, now) {
+		return nil
+	}
+
+	var errs []error
+	for _, name := range route.channels {
+		if err := d.Dispatch(ctx, name, msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) == len(route.channels) {
+		d.routes.release(severity, msg.Key, now)
+	}
+
+	return errors.Join(errs...)
+}
+
+// claim reports whether an alert with key may be sent at now, recording the
+// send when it may so concurrent repeats are suppressed too. Alerts without a
+// key are never suppressed.
+func (r *routes) claim(severity Severity, key string, repeat time.Duration, now time.Time) bool {
+	if key == "" || repeat <= 0 {
+		return true
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := alertKey{severity: severity, key: key}
+	if last, ok := r.lastSent[id]; ok && now.Sub(last) < repeat {
+		return false
+	}
+	r.lastSent[id] = now
+
+	// Forget alerts whose interval has passed so the map stays bounded by
+	// the alerts currently repeating.
+	if now.Sub(r.lastSweep) >= routeSweepInterval {
+		for id, last := range r.lastSent {
+			if now.Sub(last) >= r.bySeverity[id.severity].repeat {
+				delete(r.lastSent, id)
+			}
+		}
+		r.lastSweep = now
+	}
+
+	return true
+}
+
+// release undoes the claim made at claimedAt for an alert that was not
+// delivered, unless a later claim replaced it.
+func (r *routes) release(severity Severity, key string, claimedAt time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := alertKey{severity: severity, key: key}
+	if last, ok := r.lastSent[id]; ok && last.Equal(claimedAt) {
+		delete(r.lastSent, id)
+	}
+}
@@ -0,0 +1,77 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"marketflash/internal/config"
+)
+
+func TestNotifyRouting(t *testing.T) {
+	ctx := context.Background()
+	d, _, _ := newTestDispatcher(config.RetryConfig{MaxAttempts: 1})
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+
+	email, pager := &fakeNotifier{}, &fakeNotifier{}
+	d.Register("email", email, config.RateConfig{})
+	d.Register("pager", pager, config.RateConfig{})
+
+	err := d.SetRouting(map[string]config.SeverityRoute{
+		"info":     {Channels: []string{"email"}},
+		"critical": {Channels: []string{"email", "pager"}, RepeatInterval: 10 * time.Minute},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	for _, msg := range []Message{
+		{ID: "1", Title: "AAPL crossed 200"},
+		{ID: "2", Key: "margin", Severity: SeverityCritical, Title: "Margin call"},
+		{ID: "3", Key: "margin", Severity: SeverityCritical, Title: "Margin call"},
+	} {
+		if err := d.Notify(ctx, msg); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+	}
+
+	if len(email.sent) != 2 || email.sent[0].ID != "1" || email.sent[1].ID != "2" {
+		t.Errorf("expected info and the first critical alert by email, got %+v", email.sent)
+	}
+	if len(pager.sent) != 1 || pager.sent[0].ID != "2" {
+		t.Errorf("expected the first critical alert on the pager, got %+v", pager.sent)
+	}
+
+	now = now.Add(10 * time.Minute)
+	if err := d.Notify(ctx, Message{ID: "4", Key: "margin", Severity: SeverityCritical}); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(pager.sent) != 2 || pager.sent[1].ID != "4" {
+		t.Errorf("expected the alert to repeat after the interval, got %+v", pager.sent)
+	}
+
+	// An alert no channel accepted is not counted, so its retry goes out.
+	now = now.Add(10 * time.Minute)
+	email.errs = []error{ErrPermanent}
+	pager.errs = []error{ErrPermanent}
+	if err := d.Notify(ctx, Message{ID: "5", Key: "margin", Severity: SeverityCritical}); err == nil {
+		t.Fatal("expected the failed deliveries to be reported")
+	}
+	if err := d.Notify(ctx, Message{ID: "6", Key: "margin", Severity: SeverityCritical}); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(pager.sent) != 3 || pager.sent[2].ID != "6" {
+		t.Errorf("expected the retry after a failed alert to be sent, got %+v", pager.sent)
+	}
+
+	if err := d.Notify(ctx, Message{ID: "7", Severity: SeverityWarning}); !errors.Is(err, ErrNoRoute) {
+		t.Errorf("expected error %v, got: %v", ErrNoRoute, err)
+	}
+
+	err = d.SetRouting(map[string]config.SeverityRoute{"info": {Channels: []string{"missing"}}})
+	if !errors.Is(err, ErrUnknownChannel) {
+		t.Errorf("expected error %v, got: %v", ErrUnknownChannel, err)
+	}
+}
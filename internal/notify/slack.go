@@ -0,0 +1,26 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+)
+
+// Slack posts messages to a Slack incoming webhook.
+type Slack struct {
+	url    string
+	client *http.Client
+}
+
+// NewSlack returns a notifier for the incoming webhook at url.
+func NewSlack(url string) *Slack {
+	return &Slack{url: url, client: defaultClient}
+}
+
+func (s *Slack) Send(ctx context.Context, msg Message) error {
+	text := msg.Body
+	if msg.Title != "" {
+		text = "*" + msg.Title + "*\n" + msg.Body
+	}
+
+	return postJSON(ctx, s.client, s.url, nil, mustJSON(map[string]string{"text": text}))
+}
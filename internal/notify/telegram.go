@@ -0,0 +1,40 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+)
+
+const telegramAPI = "https://api.telegram.org"
+
+// Telegram sends messages to a chat through a Telegram bot.
+type Telegram struct {
+	baseURL string
+	token   string
+	chatID  string
+	client  *http.Client
+}
+
+// NewTelegram returns a notifier posting as the bot with token to chatID.
+func NewTelegram(token, chatID string) *Telegram {
+	return &Telegram{
+		baseURL: telegramAPI,
+		token:   token,
+		chatID:  chatID,
+		client:  defaultClient,
+	}
+}
+
+func (t *Telegram) Send(ctx context.Context, msg Message) error {
+	text := msg.Body
+	if msg.Title != "" {
+		text = msg.Title + "\n\n" + msg.Body
+	}
+
+	body := mustJSON(map[string]string{
+		"chat_id": t.chatID,
+		"text":    text,
+	})
+
+	return postJSON(ctx, t.client, t.baseURL+"/bot"+t.token+"/sendMessage", nil, body)
+}
@@ -0,0 +1,356 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+	"text/template/parse"
+	"time"
+)
+
+var (
+	ErrInvalidTemplate  = errors.New("invalid notification template")
+	ErrTemplateNotFound = errors.New("template not found")
+	ErrMissingTemplate  = errors.New("template name is required")
+)
+
+// Templates come from API clients, so rendering one is bounded: a template
+// may nest at most maxTemplateDepth if or with blocks, must not loop, and
+// stops with an error once its output passes maxTemplateOutput bytes or it
+// runs past renderTimeout.
+const (
+	maxTemplateDepth  = 8
+	maxTemplateOutput = 64 << 10
+	renderTimeout     = time.Second
+)
+
+// AlertData is what templates render. Its fields are available as
+// {{.Symbol}}, {{.Price}} and so on; referencing anything else is a lint
+// error.
+type AlertData struct {
+	Symbol      string
+	Price       float64
+	Condition   string
+	Threshold   float64
+	TriggeredAt time.Time
+}
+
+// SampleAlert is the data rendered when linting and test-sending templates.
+var SampleAlert = AlertData{
+	Symbol:      "AAPL",
+	Price:       201.37,
+	Condition:   "above",
+	Threshold:   200,
+	TriggeredAt: time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC),
+}
+
+// Template formats alert notifications with text/template syntax.
+type Template struct {
+	ID        string    `json:"id"`
+	Owner     string    `json:"-"`
+	Name      string    `json:"name"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Lint reports parse errors, unsupported constructs and references to unknown
+// fields anywhere in the template, then renders it against SampleAlert to
+// catch what only fails at render time.
+func (t Template) Lint() error {
+	if strings.TrimSpace(t.Name) == "" {
+		return ErrMissingTemplate
+	}
+	_, err := t.Render(SampleAlert)
+	return err
+}
+
+// Render formats data into a Message.
+func (t Template) Render(data AlertData) (Message, error) {
+	title, err := execute("title", t.Title, data)
+	if err != nil {
+		return Message{}, err
+	}
+
+	body, err := execute("body", t.Body, data)
+	if err != nil {
+		return Message{}, err
+	}
+
+	return Message{Title: title, Body: body}, nil
+}
+
+func execute(name, text string, data AlertData) (string, error) {
+	tmpl, err := parseTemplate(name, text)
+	if err != nil {
+		return "", err
+	}
+
+	w := &boundedWriter{max: maxTemplateOutput, deadline: time.Now().Add(renderTimeout)}
+	if err := tmpl.Execute(w, data); err != nil {
+		return "", fmt.Errorf("%w: %s", ErrInvalidTemplate, err)
+	}
+
+	return w.b.String(), nil
+}
+
+// parseTemplate parses text, rejecting references to fields AlertData does
+// not have, loops, template definitions and calls, and blocks nested deeper
+// than maxTemplateDepth.
+func parseTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTemplate, err)
+	}
+
+	if len(tmpl.Templates()) > 1 {
+		return nil, fmt.Errorf("%w: %s: defining templates is not supported", ErrInvalidTemplate, name)
+	}
+	if tmpl.Tree == nil {
+		return tmpl, nil
+	}
+
+	c := templateChecker{tree: tmpl.Tree}
+	if err := c.check(tmpl.Tree.Root, alertDataType, 0); err != nil {
+		return nil, err
+	}
+
+	return tmpl, nil
+}
+
+// templateChecker walks a parsed template for constructs parseTemplate
+// rejects and for field references AlertData cannot satisfy. Checking the
+// tree rather than a sample render covers branches the sample never takes.
+type templateChecker struct {
+	tree *parse.Tree
+}
+
+var alertDataType = reflect.TypeFor[AlertData]()
+
+// check walks node with dot of type dot; a nil dot is one whose type is not
+// known statically, and fields on it are left to render time.
+func (c templateChecker) check(node parse.Node, dot reflect.Type, depth int) error {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, child := range n.Nodes {
+			if err := c.check(child, dot, depth); err != nil {
+				return err
+			}
+		}
+	case *parse.ActionNode:
+		_, err := c.pipeType(n.Pipe, dot)
+		return err
+	case *parse.IfNode:
+		if _, err := c.pipeType(n.Pipe, dot); err != nil {
+			return err
+		}
+		return c.checkBranch(n, &n.BranchNode, dot, dot, depth)
+	case *parse.WithNode:
+		inner, err := c.pipeType(n.Pipe, dot)
+		if err != nil {
+			return err
+		}
+		return c.checkBranch(n, &n.BranchNode, inner, dot, depth)
+	case *parse.RangeNode:
+		return c.errorf(n, "range is not supported")
+	case *parse.TemplateNode:
+		return c.errorf(n, "template calls are not supported")
+	}
+	return nil
+}
+
+func (c templateChecker) checkBranch(n parse.Node, b *parse.BranchNode, dot, elseDot reflect.Type, depth int) error {
+	if depth >= maxTemplateDepth {
+		return c.errorf(n, "blocks nested more than %d deep", maxTemplateDepth)
+	}
+	if err := c.check(b.List, dot, depth+1); err != nil {
+		return err
+	}
+	return c.check(b.ElseList, elseDot, depth+1)
+}
+
+// pipeType checks every argument in pipe and returns the type it evaluates
+// to when that is known: a pipeline of a single field reference.
+func (c templateChecker) pipeType(pipe *parse.PipeNode, dot reflect.Type) (reflect.Type, error) {
+	if pipe == nil {
+		return nil, nil
+	}
+
+	var result reflect.Type
+	for _, cmd := range pipe.Cmds {
+		for _, arg := range cmd.Args {
+			typ, err := c.argType(arg, dot)
+			if err != nil {
+				return nil, err
+			}
+			if len(pipe.Cmds) == 1 && len(cmd.Args) == 1 {
+				result = typ
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func (c templateChecker) argType(arg parse.Node, dot reflect.Type) (reflect.Type, error) {
+	switch n := arg.(type) {
+	case *parse.DotNode:
+		return dot, nil
+	case *parse.FieldNode:
+		return c.fieldType(n, dot, n.Ident)
+	case *parse.VariableNode:
+		if n.Ident[0] != "$" {
+			return nil, nil // declared variables are not tracked
+		}
+		return c.fieldType(n, alertDataType, n.Ident[1:])
+	case *parse.ChainNode:
+		typ, err := c.argType(n.Node, dot)
+		if err != nil {
+			return nil, err
+		}
+		return c.fieldType(n, typ, n.Field)
+	case *parse.PipeNode:
+		return c.pipeType(n, dot)
+	}
+	return nil, nil
+}
+
+// fieldType resolves the chain of field or method names against typ the way
+// text/template does, returning the type of the last one.
+func (c templateChecker) fieldType(n parse.Node, typ reflect.Type, idents []string) (reflect.Type, error) {
+	for _, ident := range idents {
+		if typ == nil || typ.Kind() == reflect.Interface {
+			return nil, nil
+		}
+
+		if m, ok := reflect.PointerTo(typ).MethodByName(ident); ok {
+			if m.Type.NumOut() == 0 {
+				return nil, nil
+			}
+			typ = m.Type.Out(0)
+			continue
+		}
+
+		base := typ
+		if base.Kind() == reflect.Pointer {
+			base = base.Elem()
+		}
+		switch base.Kind() {
+		case reflect.Struct:
+			f, ok := base.FieldByName(ident)
+			if !ok || !f.IsExported() {
+				return nil, c.errorf(n, "can't evaluate field %s in type %s", ident, typ)
+			}
+			typ = f.Type
+		case reflect.Map:
+			typ = base.Elem()
+		default:
+			return nil, c.errorf(n, "can't evaluate field %s in type %s", ident, typ)
+		}
+	}
+	return typ, nil
+}
+
+func (c templateChecker) errorf(n parse.Node, format string, args ...any) error {
+	location, _ := c.tree.ErrorContext(n)
+	return fmt.Errorf("%w: %s: %s", ErrInvalidTemplate, location, fmt.Sprintf(format, args...))
+}
+
+// boundedWriter collects template output, failing writes past max bytes or
+// after deadline so a render cannot run away.
+type boundedWriter struct {
+	b        strings.Builder
+	max      int
+	deadline time.Time
+}
+
+func (w *boundedWriter) Write(p []byte) (int, error) {
+	if time.Now().After(w.deadline) {
+		return 0, fmt.Errorf("rendering took longer than %s", renderTimeout)
+	}
+	if w.b.Len()+len(p) > w.max {
+		return 0, fmt.Errorf("output is larger than %d bytes", w.max)
+	}
+	return w.b.Write(p)
+}
+
+// TemplateStore persists templates. Lookups are scoped to an owner so one API
+// key can never read or modify another's templates.
+type TemplateStore interface {
+	Save(ctx context.Context, t Template) error
+	Get(ctx context.Context, owner, id string) (Template, error)
+	List(ctx context.Context, owner string) ([]Template, error)
+	Delete(ctx context.Context, owner, id string) error
+}
+
+// MemoryTemplates is an in-process TemplateStore, used in tests and
+// development.
+type MemoryTemplates struct {
+	mu        sync.RWMutex
+	templates map[string]Template // by ID
+}
+
+// NewMemoryTemplates returns an empty MemoryTemplates.
+func NewMemoryTemplates() *MemoryTemplates {
+	return &MemoryTemplates{templates: make(map[string]Template)}
+}
+
+func (s *MemoryTemplates) Save(_ context.Context, t Template) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.templates[t.ID]; ok && existing.Owner != t.Owner {
+		return ErrTemplateNotFound
+	}
+	s.templates[t.ID] = t
+
+	return nil
+}
+
+func (s *MemoryTemplates) Get(_ context.Context, owner, id string) (Template, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, ok := s.templates[id]
+	if !ok || t.Owner != owner {
+		return Template{}, ErrTemplateNotFound
+	}
+
+	return t, nil
+}
+
+func (s *MemoryTemplates) List(_ context.Context, owner string) ([]Template, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []Template
+	for _, t := range s.templates {
+		if t.Owner == owner {
+			out = append(out, t)
+		}
+	}
+	slices.SortFunc(out, func(a, b Template) int { return strings.Compare(a.Name, b.Name) })
+
+	return out, nil
+}
+
+func (s *MemoryTemplates) Delete(_ context.Context, owner, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.templates[id]
+	if !ok || t.Owner != owner {
+		return ErrTemplateNotFound
+	}
+	delete(s.templates, id)
+
+	return nil
+}
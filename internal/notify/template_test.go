@@ -0,0 +1,100 @@
+package notify
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestTemplateLint(t *testing.T) {
+	tests := []struct {
+		name    string
+		tmpl    Template
+		wantErr error
+	}{
+		{
+			name: "valid",
+			tmpl: Template{Name: "price", Title: "{{.Symbol}} {{.Condition}} {{.Threshold}}", Body: "Last {{printf \"%.2f\" .Price}} at {{.TriggeredAt.Format \"15:04\"}}"},
+		},
+		{
+			name: "nested fields",
+			tmpl: Template{Name: "with", Body: "{{with .TriggeredAt}}{{.Year}}{{end}} {{$.Symbol}} {{(.TriggeredAt).Weekday}}"},
+		},
+		{
+			name:    "missing name",
+			tmpl:    Template{Title: "{{.Symbol}}"},
+			wantErr: ErrMissingTemplate,
+		},
+		{
+			name:    "parse error",
+			tmpl:    Template{Name: "broken", Title: "{{.Symbol"},
+			wantErr: ErrInvalidTemplate,
+		},
+		{
+			name:    "range over a number",
+			tmpl:    Template{Name: "loop", Body: "{{range 1000000000}}{{range 1000000000}}x{{end}}{{end}}"},
+			wantErr: ErrInvalidTemplate,
+		},
+		{
+			name:    "recursive template",
+			tmpl:    Template{Name: "loop", Body: `{{define "x"}}{{template "x"}}{{end}}{{template "x"}}`},
+			wantErr: ErrInvalidTemplate,
+		},
+		{
+			name:    "nested too deep",
+			tmpl:    Template{Name: "deep", Body: strings.Repeat("{{if .Symbol}}", maxTemplateDepth+1) + strings.Repeat("{{end}}", maxTemplateDepth+1)},
+			wantErr: ErrInvalidTemplate,
+		},
+		{
+			name:    "output too large",
+			tmpl:    Template{Name: "big", Body: `{{printf "%999999d" 1}}`},
+			wantErr: ErrInvalidTemplate,
+		},
+		{
+			name:    "unknown field",
+			tmpl:    Template{Name: "typo", Body: "{{.Symbl}} crossed"},
+			wantErr: ErrInvalidTemplate,
+		},
+		{
+			name:    "unknown field in a branch not taken",
+			tmpl:    Template{Name: "typo", Body: "{{if false}}{{.Bogus}}{{end}}"},
+			wantErr: ErrInvalidTemplate,
+		},
+		{
+			name:    "unknown field under with",
+			tmpl:    Template{Name: "typo", Body: "{{with .TriggeredAt}}{{.Symbol}}{{end}}"},
+			wantErr: ErrInvalidTemplate,
+		},
+		{
+			name:    "unknown field on root variable",
+			tmpl:    Template{Name: "typo", Title: "{{if .Symbol}}{{else}}{{$.Sym}}{{end}}"},
+			wantErr: ErrInvalidTemplate,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.tmpl.Lint()
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Errorf("expected no error, got: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected error %v, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestTemplateRender(t *testing.T) {
+	tmpl := Template{Name: "price", Title: "{{.Symbol}} {{.Condition}} {{.Threshold}}", Body: "Last {{.Price}}"}
+
+	msg, err := tmpl.Render(SampleAlert)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if msg.Title != "AAPL above 200" || msg.Body != "Last 201.37" {
+		t.Errorf("unexpected message: %+v", msg)
+	}
+}
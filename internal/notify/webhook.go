@@ -0,0 +1,59 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	SignatureHeader = "X-Marketflash-Signature"
+	TimestampHeader = "X-Marketflash-Timestamp"
+)
+
+// Webhook posts messages as JSON to an arbitrary URL. With a secret, each
+// request carries an HMAC-SHA256 signature over "<timestamp>.<body>" so
+// receivers can verify origin and reject replays.
+type Webhook struct {
+	url    string
+	secret []byte
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewWebhook returns a webhook notifier. An empty secret disables signing.
+func NewWebhook(url, secret string) *Webhook {
+	return &Webhook{
+		url:    url,
+		secret: []byte(secret),
+		client: defaultClient,
+		now:    time.Now,
+	}
+}
+
+func (w *Webhook) Send(ctx context.Context, msg Message) error {
+	body := mustJSON(msg)
+	header := http.Header{}
+
+	if len(w.secret) > 0 {
+		ts := strconv.FormatInt(w.now().Unix(), 10)
+		header.Set(TimestampHeader, ts)
+		header.Set(SignatureHeader, "sha256="+Sign(w.secret, ts, body))
+	}
+
+	return postJSON(ctx, w.client, w.url, header, body)
+}
+
+// Sign returns the hex HMAC-SHA256 of "<timestamp>.<body>" under secret, as
+// sent in SignatureHeader.
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,370 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	"marketflash/internal/config"
+)
+
+const defaultStaleAfter = 30 * time.Second
+
+// Source is a provider with the name it is configured under.
+type Source struct {
+	Name     string
+	Provider Provider
+}
+
+// Divergence reports a quote from Provider differing from Reference's latest
+// quote for the same symbol by more than the reconcile tolerance.
+type Divergence struct {
+	Symbol         string    `json:"symbol"`
+	Provider       string    `json:"provider"`
+	Price          float64   `json:"price"`
+	Reference      string    `json:"reference"`
+	ReferencePrice float64   `json:"reference_price"`
+	At             time.Time `json:"at"`
+}
+
+// LogDivergences logs every divergence received from divergences until ctx
+// is cancelled; wrap it with app.NewBackground.
+func LogDivergences(ctx context.Context, divergences <-chan Divergence) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case d := <-divergences:
+			slog.Warn("provider quotes diverged",
+				"symbol", d.Symbol,
+				"provider", d.Provider,
+				"price", d.Price,
+				"reference", d.Reference,
+				"reference_price", d.ReferencePrice)
+		}
+	}
+}
+
+type quote struct {
+	price float64
+	at    time.Time
+}
+
+// divergencePair identifies two sources disagreeing on a symbol; a is the
+// lower source index.
+type divergencePair struct {
+	symbol string
+	a, b   int
+}
+
+type sourceState struct {
+	Source
+	lastTick time.Time
+	down     bool
+	quotes   map[string]quote // latest by symbol
+}
+
+// Failover streams ticks from the highest-priority healthy source. A source
+// is unhealthy once its Run returns or it sends nothing for staleAfter.
+// Providers are expected to reconnect on their own; a source whose Run
+// returns is down until it ticks again. Every source is subscribed to every
+// symbol so fallbacks are warm and quotes can be reconciled.
+type Failover struct {
+	sources     []*sourceState
+	staleAfter  time.Duration
+	tolerance   float64
+	ticks       chan Tick
+	divergences chan Divergence
+	now         func() time.Time
+
+	mu        sync.Mutex
+	active    int
+	priority  []int // source indexes, most preferred first
+	diverging map[divergencePair]bool
+}
+
+// NewFailover returns a Failover over sources, the first being the primary.
+func NewFailover(cfg config.FailoverConfig, sources []Source) *Failover {
+	f := &Failover{
+		staleAfter:  defaultStaleAfter,
+		tolerance:   cfg.ReconcileTolerance,
+		ticks:       make(chan Tick, tickBuffer),
+		divergences: make(chan Divergence, tickBuffer),
+		now:         time.Now,
+		diverging:   make(map[divergencePair]bool),
+	}
+	if cfg.StaleAfter > 0 {
+		f.staleAfter = cfg.StaleAfter
+	}
+
+	for i, s := range sources {
+		f.sources = append(f.sources, &sourceState{Source: s, quotes: make(map[string]quote)})
+		f.priority = append(f.priority, i)
+	}
+
+	return f
+}
+
+func (f *Failover) Subscribe(ctx context.Context, symbols []string) error {
+	var errs []error
+	for _, s := range f.sources {
+		if err := s.Provider.Subscribe(ctx, symbols); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (f *Failover) Unsubscribe(ctx context.Context, symbols []string) error {
+	var errs []error
+	for _, s := range f.sources {
+		if err := s.Provider.Unsubscribe(ctx, symbols); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (f *Failover) Ticks() <-chan Tick {
+	return f.ticks
+}
+
+// Divergences returns quote disagreements between sources; see
+// LogDivergences. It only receives when a reconcile tolerance is configured,
+// and drops reports when full. Each pair of sources is reported once per
+// symbol when they start to disagree, and again only after they have agreed
+// in between.
+func (f *Failover) Divergences() <-chan Divergence {
+	return f.divergences
+}
+
+// Active returns the name of the source ticks are currently taken from.
+func (f *Failover) Active() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.sources[f.active].Name
+}
+
+// Priority returns the source names, most preferred first.
+func (f *Failover) Priority() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	names := make([]string, len(f.priority))
+	for i, idx := range f.priority {
+		names[i] = f.sources[idx].Name
+	}
+	return names
+}
+
+// SetPriority reorders the sources; names must be a permutation of the
+// current source names. The active source is re-evaluated straight away.
+func (f *Failover) SetPriority(names []string) error {
+	f.mu.Lock()
+	if len(names) != len(f.sources) {
+		f.mu.Unlock()
+		return fmt.Errorf("%w: want %d providers, got %d", ErrInvalidPriority, len(f.sources), len(names))
+	}
+
+	used := make([]bool, len(f.sources))
+	priority := make([]int, 0, len(names))
+	for _, name := range names {
+		idx := -1
+		for i, s := range f.sources {
+			if s.Name == name && !used[i] {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			f.mu.Unlock()
+			return fmt.Errorf("%w: unexpected provider %q", ErrInvalidPriority, name)
+		}
+		used[idx] = true
+		priority = append(priority, idx)
+	}
+	f.priority = priority
+	f.mu.Unlock()
+
+	f.evaluate()
+	return nil
+}
+
+// Reconnect reconnects the active source.
+func (f *Failover) Reconnect(ctx context.Context) error {
+	f.mu.Lock()
+	src := f.sources[f.active]
+	f.mu.Unlock()
+
+	r, ok := src.Provider.(Reconnecter)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrCannotReconnect, src.Name)
+	}
+
+	return r.Reconnect(ctx)
+}
+
+type sourceEvent struct {
+	source int
+	tick   Tick
+	ended  bool
+}
+
+// fanIn runs every source until ctx is cancelled, merging their ticks and
+// exits into one channel. wait blocks until every goroutine has returned and
+// must only be called once ctx is done.
+func fanIn(ctx context.Context, sources []Source) (events <-chan sourceEvent, wait func()) {
+	ch := make(chan sourceEvent)
+	var wg sync.WaitGroup
+
+	send := func(ev sourceEvent) bool {
+		select {
+		case ch <- ev:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	for i, s := range sources {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_ = s.Provider.Run(ctx)
+			send(sourceEvent{source: i, ended: true})
+		}()
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case t := <-s.Provider.Ticks():
+					if !send(sourceEvent{source: i, tick: t}) {
+						return
+					}
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	return ch, wg.Wait
+}
+
+// Run runs every source and switches between them until ctx is cancelled.
+func (f *Failover) Run(ctx context.Context) error {
+	f.mu.Lock()
+	for _, s := range f.sources {
+		// Sources get one staleAfter of grace to deliver their first tick.
+		s.lastTick = f.now()
+	}
+	f.mu.Unlock()
+
+	sources := make([]Source, len(f.sources))
+	for i, s := range f.sources {
+		sources[i] = s.Source
+	}
+	events, wait := fanIn(ctx, sources)
+
+	check := time.NewTicker(f.staleAfter / 2)
+	defer check.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			wait()
+			return nil
+		case ev := <-events:
+			if ev.ended {
+				f.markDown(ev.source)
+			} else {
+				f.handleTick(ev.source, ev.tick)
+			}
+		case <-check.C:
+			f.evaluate()
+		}
+	}
+}
+
+func (f *Failover) markDown(i int) {
+	f.mu.Lock()
+	f.sources[i].down = true
+	f.mu.Unlock()
+
+	f.evaluate()
+}
+
+// handleTick records a tick, forwards it when it comes from the active
+// source, and reconciles it against the other sources. A tick from a source
+// marked down brings it back; evaluate picks it up on its next run.
+func (f *Failover) handleTick(i int, t Tick) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := f.now()
+	src := f.sources[i]
+	src.down = false
+	src.lastTick = now
+	src.quotes[t.Symbol] = quote{price: t.Price, at: now}
+
+	if i == f.active {
+		select {
+		case f.ticks <- t:
+		default:
+		}
+	}
+
+	if f.tolerance <= 0 {
+		return
+	}
+
+	for j, ref := range f.sources {
+		q, ok := ref.quotes[t.Symbol]
+		if j == i || !ok || now.Sub(q.at) > f.staleAfter || q.price == 0 {
+			continue
+		}
+		pair := divergencePair{symbol: t.Symbol, a: min(i, j), b: max(i, j)}
+		if math.Abs(t.Price-q.price)/q.price <= f.tolerance {
+			delete(f.diverging, pair)
+			continue
+		}
+		if f.diverging[pair] {
+			continue
+		}
+
+		select {
+		case f.divergences <- Divergence{
+			Symbol:         t.Symbol,
+			Provider:       src.Name,
+			Price:          t.Price,
+			Reference:      ref.Name,
+			ReferencePrice: q.price,
+			At:             now,
+		}:
+			f.diverging[pair] = true
+		default:
+		}
+	}
+}
+
+// evaluate switches to the highest-priority healthy source, failing back to
+// the primary once it recovers. With no healthy source the active one is
+// kept.
+func (f *Failover) evaluate() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := f.now()
+	for _, i := range f.priority {
+		s := f.sources[i]
+		if !s.down && now.Sub(s.lastTick) <= f.staleAfter {
+			f.active = i
+			return
+		}
+	}
+}
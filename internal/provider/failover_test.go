@@ -0,0 +1,223 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"marketflash/internal/config"
+	"marketflash/internal/ratelimit"
+)
+
+// fakeProvider emits whatever is pushed to its ticks channel and runs until
+// cancelled or stopped.
+type fakeProvider struct {
+	ticks      chan Tick
+	stop       chan struct{}
+	subscribed []string
+}
+
+func newFakeProvider() *fakeProvider {
+	return &fakeProvider{ticks: make(chan Tick), stop: make(chan struct{})}
+}
+
+func (p *fakeProvider) Subscribe(_ context.Context, symbols []string) error {
+	p.subscribed = append(p.subscribed, symbols...)
+	return nil
+}
+
+func (p *fakeProvider) Unsubscribe(context.Context, []string) error { return nil }
+
+func (p *fakeProvider) Ticks() <-chan Tick { return p.ticks }
+
+func (p *fakeProvider) Run(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+	case <-p.stop:
+	}
+	return nil
+}
+
+func TestFailover(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	primary, secondary := newFakeProvider(), newFakeProvider()
+
+	f := NewFailover(config.FailoverConfig{StaleAfter: 10 * time.Second}, []Source{
+		{Name: "primary", Provider: primary},
+		{Name: "secondary", Provider: secondary},
+	})
+	f.now = func() time.Time { return now }
+	for _, s := range f.sources {
+		s.lastTick = now
+	}
+
+	_ = f.Subscribe(context.Background(), []string{"AAPL"})
+	if len(primary.subscribed) != 1 || len(secondary.subscribed) != 1 {
+		t.Fatalf("expected every source to be subscribed, got %v and %v", primary.subscribed, secondary.subscribed)
+	}
+
+	f.handleTick(0, Tick{Symbol: "AAPL", Price: 200})
+	f.handleTick(1, Tick{Symbol: "AAPL", Price: 200.1})
+	if got := <-f.Ticks(); got.Price != 200 {
+		t.Errorf("expected primary tick, got %+v", got)
+	}
+	if len(f.Ticks()) != 0 {
+		t.Errorf("expected secondary ticks not to be forwarded")
+	}
+
+	// The primary goes quiet while the secondary keeps ticking.
+	now = now.Add(11 * time.Second)
+	f.handleTick(1, Tick{Symbol: "AAPL", Price: 200.2})
+	f.evaluate()
+	if got := f.Active(); got != "secondary" {
+		t.Fatalf("expected failover to secondary, got %s", got)
+	}
+
+	f.handleTick(1, Tick{Symbol: "AAPL", Price: 200.3})
+	if got := <-f.Ticks(); got.Price != 200.3 {
+		t.Errorf("expected secondary tick after failover, got %+v", got)
+	}
+
+	f.handleTick(0, Tick{Symbol: "AAPL", Price: 200.4})
+	f.evaluate()
+	if got := f.Active(); got != "primary" {
+		t.Errorf("expected failback to primary, got %s", got)
+	}
+
+	f.markDown(0)
+	if got := f.Active(); got != "secondary" {
+		t.Errorf("expected failover after disconnect, got %s", got)
+	}
+
+	f.markDown(1)
+	if got := f.Active(); got != "secondary" {
+		t.Errorf("expected active source to be kept with none healthy, got %s", got)
+	}
+
+	// A source that ticks again after its Run returned recovers.
+	f.handleTick(0, Tick{Symbol: "AAPL", Price: 200.5})
+	f.evaluate()
+	if got := f.Active(); got != "primary" {
+		t.Errorf("expected primary to recover once it ticks again, got %s", got)
+	}
+}
+
+func TestFailoverReconcile(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	f := NewFailover(config.FailoverConfig{ReconcileTolerance: 0.01}, []Source{
+		{Name: "primary", Provider: newFakeProvider()},
+		{Name: "secondary", Provider: newFakeProvider()},
+	})
+	f.now = func() time.Time { return now }
+
+	f.handleTick(0, Tick{Symbol: "AAPL", Price: 200})
+	f.handleTick(1, Tick{Symbol: "AAPL", Price: 201})
+	if len(f.Divergences()) != 0 {
+		t.Fatalf("expected no divergence within tolerance, got %d", len(f.Divergences()))
+	}
+
+	f.handleTick(1, Tick{Symbol: "AAPL", Price: 205})
+	select {
+	case d := <-f.Divergences():
+		if d.Provider != "secondary" || d.Reference != "primary" || d.Price != 205 || d.ReferencePrice != 200 {
+			t.Errorf("unexpected divergence: %+v", d)
+		}
+	default:
+		t.Fatal("expected a divergence")
+	}
+
+	// The same disagreement is not reported again from either side.
+	f.handleTick(1, Tick{Symbol: "AAPL", Price: 206})
+	f.handleTick(0, Tick{Symbol: "AAPL", Price: 200})
+	if len(f.Divergences()) != 0 {
+		t.Fatalf("expected an ongoing divergence to be reported once, got %d more", len(f.Divergences()))
+	}
+
+	// Once the sources agree, a new disagreement is reported.
+	f.handleTick(1, Tick{Symbol: "AAPL", Price: 200})
+	f.handleTick(0, Tick{Symbol: "AAPL", Price: 190})
+	if d := <-f.Divergences(); d.Provider != "primary" || d.Price != 190 {
+		t.Errorf("unexpected divergence: %+v", d)
+	}
+
+	now = now.Add(time.Minute)
+	f.handleTick(1, Tick{Symbol: "AAPL", Price: 300})
+	if len(f.Divergences()) != 0 {
+		t.Errorf("expected stale reference quotes to be ignored")
+	}
+}
+
+func TestFailoverRun(t *testing.T) {
+	primary, secondary := newFakeProvider(), newFakeProvider()
+	f := NewFailover(config.FailoverConfig{StaleAfter: time.Hour}, []Source{
+		{Name: "primary", Provider: primary},
+		{Name: "secondary", Provider: secondary},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- f.Run(ctx) }()
+
+	primary.ticks <- Tick{Symbol: "AAPL", Price: 1}
+	if got := <-f.Ticks(); got.Price != 1 {
+		t.Errorf("expected primary tick, got %+v", got)
+	}
+
+	close(primary.stop)
+	deadline := time.After(time.Second)
+	for f.Active() != "secondary" {
+		select {
+		case <-deadline:
+			t.Fatal("expected failover after primary disconnected")
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	secondary.ticks <- Tick{Symbol: "AAPL", Price: 2}
+	if got := <-f.Ticks(); got.Price != 2 {
+		t.Errorf("expected secondary tick, got %+v", got)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("expected no error, got: %v", err)
+	}
+}
+
+func TestNewFromConfig(t *testing.T) {
+	p, err := NewFromConfig("simulator", config.FailoverConfig{}, nil, config.SimulatorConfig{}, nil)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if _, ok := p.(*Simulator); !ok {
+		t.Errorf("expected a bare simulator without failover, got %T", p)
+	}
+
+	p, err = NewFromConfig("simulator", config.FailoverConfig{Providers: []string{"simulator"}}, nil, config.SimulatorConfig{}, nil)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if f, ok := p.(*Failover); !ok || len(f.sources) != 2 {
+		t.Errorf("expected failover over two sources, got %T", p)
+	}
+
+	outbound := ratelimit.NewOutbound()
+	outbound.Limit("simulator", 1.0/60, 1)
+	p, err = NewFromConfig("simulator", config.FailoverConfig{}, nil, config.SimulatorConfig{}, outbound)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if _, ok := p.(Reconnecter); !ok {
+		t.Errorf("expected a throttled provider to keep reconnecting, got %T", p)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := p.Subscribe(ctx, []string{"AAPL"}); err != nil {
+		t.Fatalf("expected the first subscription to pass, got: %v", err)
+	}
+	if err := p.Subscribe(ctx, []string{"MSFT"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the second subscription to wait for the quota, got: %v", err)
+	}
+}
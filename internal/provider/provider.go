@@ -0,0 +1,154 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"marketflash/internal/config"
+	"marketflash/internal/ratelimit"
+)
+
+var (
+	ErrUnknownProvider = errors.New("unknown market data provider")
+	ErrCannotReconnect = errors.New("provider does not support reconnecting")
+	ErrInvalidPriority = errors.New("invalid provider priority")
+	ErrUnknownRoute    = errors.New("unknown provider route")
+)
+
+// Tick is a single trade reported by a provider.
+type Tick struct {
+	Symbol string    `json:"symbol"`
+	Price  float64   `json:"price"`
+	Size   int64     `json:"size"`
+	Time   time.Time `json:"time"`
+}
+
+// Provider streams ticks for a changing set of symbols. It satisfies
+// watchlist.Subscriber, so the watchlist service can drive subscriptions.
+type Provider interface {
+	Subscribe(ctx context.Context, symbols []string) error
+	Unsubscribe(ctx context.Context, symbols []string) error
+
+	// Ticks returns the stream of ticks for subscribed symbols.
+	Ticks() <-chan Tick
+
+	// Run streams until ctx is cancelled; wrap it with app.NewBackground.
+	Run(ctx context.Context) error
+}
+
+// Reconnecter is implemented by providers that can drop and re-establish
+// their upstream streams on demand, e.g. from the admin API.
+type Reconnecter interface {
+	Reconnect(ctx context.Context) error
+}
+
+// NewFromConfig returns the primary provider, wrapped in a Failover when
+// fallbacks or reconciliation are configured, or a Router when routes are.
+// Subscription calls to every provider wait for its quota in outbound, which
+// may be nil.
+func NewFromConfig(primary string, failover config.FailoverConfig, routing []config.RouteConfig, simulator config.SimulatorConfig, outbound *ratelimit.Outbound) (Provider, error) {
+	if len(routing) > 0 {
+		return newRouterFromConfig(primary, failover, routing, simulator, outbound)
+	}
+
+	p, err := newThrottled(primary, simulator, outbound)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(failover.Providers) == 0 && failover.ReconcileTolerance == 0 {
+		return p, nil
+	}
+
+	sources, err := newSources(append([]string{primary}, failover.Providers...), simulator, outbound)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewFailover(failover, sources), nil
+}
+
+func newRouterFromConfig(primary string, failover config.FailoverConfig, routing []config.RouteConfig, simulator config.SimulatorConfig, outbound *ratelimit.Outbound) (*Router, error) {
+	var routes []Route
+	for _, rc := range routing {
+		sources, err := newSources(rc.Providers, simulator, outbound)
+		if err != nil {
+			return nil, err
+		}
+		routes = append(routes, Route{Symbols: rc.Symbols, Failover: NewFailover(failover, sources)})
+	}
+
+	sources, err := newSources(append([]string{primary}, failover.Providers...), simulator, outbound)
+	if err != nil {
+		return nil, err
+	}
+	routes = append(routes, Route{Failover: NewFailover(failover, sources)})
+
+	return NewRouter(routes), nil
+}
+
+// newSources returns a fresh provider for each name. Instances are never
+// shared, since each tick channel has exactly one reader.
+func newSources(names []string, simulator config.SimulatorConfig, outbound *ratelimit.Outbound) ([]Source, error) {
+	sources := make([]Source, 0, len(names))
+	for _, name := range names {
+		p, err := newThrottled(name, simulator, outbound)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, Source{Name: name, Provider: p})
+	}
+	return sources, nil
+}
+
+// New returns the provider called name, as set by the provider config key.
+func New(name string, simulator config.SimulatorConfig) (Provider, error) {
+	switch name {
+	case "simulator":
+		return NewSimulator(simulator), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownProvider, name)
+	}
+}
+
+// newThrottled returns New(name, simulator), throttled by outbound when it
+// limits name.
+func newThrottled(name string, simulator config.SimulatorConfig, outbound *ratelimit.Outbound) (Provider, error) {
+	p, err := New(name, simulator)
+	if err != nil || outbound == nil || !outbound.Limits(name) {
+		return p, err
+	}
+	return &throttled{Provider: p, name: name, outbound: outbound}, nil
+}
+
+// throttled waits for the provider's outbound quota before each
+// subscription change, since those are the calls made upstream.
+type throttled struct {
+	Provider
+	name     string
+	outbound *ratelimit.Outbound
+}
+
+func (t *throttled) Subscribe(ctx context.Context, symbols []string) error {
+	if err := t.outbound.Wait(ctx, t.name); err != nil {
+		return err
+	}
+	return t.Provider.Subscribe(ctx, symbols)
+}
+
+func (t *throttled) Unsubscribe(ctx context.Context, symbols []string) error {
+	if err := t.outbound.Wait(ctx, t.name); err != nil {
+		return err
+	}
+	return t.Provider.Unsubscribe(ctx, symbols)
+}
+
+func (t *throttled) Reconnect(ctx context.Context) error {
+	r, ok := t.Provider.(Reconnecter)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrCannotReconnect, t.name)
+	}
+	return r.Reconnect(ctx)
+}
@@ -0,0 +1,171 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Route sends symbols matching any of Symbols, exactly or as a path.Match
+// glob, to Failover. A route without symbols matches everything.
+type Route struct {
+	Symbols  []string
+	Failover *Failover
+}
+
+// RouteStatus describes a route for operators.
+type RouteStatus struct {
+	Symbols   []string `json:"symbols"`
+	Providers []string `json:"providers"`
+	Active    string   `json:"active"`
+}
+
+// Router streams each symbol from the first route that matches it, so
+// operators can prefer different providers per symbol or asset class. Each
+// route fails over independently. Symbols matching no route are dropped.
+type Router struct {
+	routes      []Route
+	ticks       chan Tick
+	divergences chan Divergence
+}
+
+// NewRouter returns a Router over routes, tried in order. The routes'
+// failovers report divergences on the router's channel from then on.
+func NewRouter(routes []Route) *Router {
+	r := &Router{
+		ticks:       make(chan Tick, tickBuffer),
+		divergences: make(chan Divergence, tickBuffer),
+	}
+	for _, route := range routes {
+		route.Failover.divergences = r.divergences
+		symbols := make([]string, len(route.Symbols))
+		for i, s := range route.Symbols {
+			symbols[i] = strings.ToUpper(s)
+		}
+		r.routes = append(r.routes, Route{Symbols: symbols, Failover: route.Failover})
+	}
+
+	return r
+}
+
+// route returns the index of the first route matching sym, or -1.
+func (r *Router) route(sym string) int {
+	sym = strings.ToUpper(sym)
+	for i, route := range r.routes {
+		if len(route.Symbols) == 0 {
+			return i
+		}
+		for _, pattern := range route.Symbols {
+			if ok, _ := path.Match(pattern, sym); ok {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// split groups symbols by the route they belong to.
+func (r *Router) split(symbols []string) map[int][]string {
+	byRoute := make(map[int][]string)
+	for _, sym := range symbols {
+		if i := r.route(sym); i >= 0 {
+			byRoute[i] = append(byRoute[i], sym)
+		}
+	}
+	return byRoute
+}
+
+func (r *Router) Subscribe(ctx context.Context, symbols []string) error {
+	var errs []error
+	for i, syms := range r.split(symbols) {
+		if err := r.routes[i].Failover.Subscribe(ctx, syms); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Router) Unsubscribe(ctx context.Context, symbols []string) error {
+	var errs []error
+	for i, syms := range r.split(symbols) {
+		if err := r.routes[i].Failover.Unsubscribe(ctx, syms); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Router) Ticks() <-chan Tick {
+	return r.ticks
+}
+
+// Divergences returns the quote disagreements of every route; see
+// Failover.Divergences.
+func (r *Router) Divergences() <-chan Divergence {
+	return r.divergences
+}
+
+// Routes returns every route in match order.
+func (r *Router) Routes() []RouteStatus {
+	statuses := make([]RouteStatus, len(r.routes))
+	for i, route := range r.routes {
+		symbols := route.Symbols
+		if len(symbols) == 0 {
+			symbols = []string{"*"}
+		}
+		statuses[i] = RouteStatus{
+			Symbols:   symbols,
+			Providers: route.Failover.Priority(),
+			Active:    route.Failover.Active(),
+		}
+	}
+	return statuses
+}
+
+// SetPriority changes the failover order of route i; see
+// Failover.SetPriority.
+func (r *Router) SetPriority(i int, providers []string) error {
+	if i < 0 || i >= len(r.routes) {
+		return fmt.Errorf("%w: %d", ErrUnknownRoute, i)
+	}
+	return r.routes[i].Failover.SetPriority(providers)
+}
+
+// Reconnect reconnects the active source of every route.
+func (r *Router) Reconnect(ctx context.Context) error {
+	var errs []error
+	for _, route := range r.routes {
+		if err := route.Failover.Reconnect(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Run runs every route until ctx is cancelled. Ticks are dropped rather than
+// blocking when the consumer falls behind.
+func (r *Router) Run(ctx context.Context) error {
+	sources := make([]Source, len(r.routes))
+	for i, route := range r.routes {
+		sources[i] = Source{Provider: route.Failover}
+	}
+	events, wait := fanIn(ctx, sources)
+
+	for {
+		select {
+		case <-ctx.Done():
+			wait()
+			return nil
+		case ev := <-events:
+			if ev.ended {
+				continue
+			}
+			select {
+			case r.ticks <- ev.tick:
+			default:
+			}
+		}
+	}
+}
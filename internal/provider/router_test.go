@@ -0,0 +1,134 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"marketflash/internal/config"
+)
+
+func TestRouter(t *testing.T) {
+	crypto, equities := newFakeProvider(), newFakeProvider()
+	fallback, other := newFakeProvider(), newFakeProvider()
+
+	r := NewRouter([]Route{
+		{Symbols: []string{"*-usd"}, Failover: NewFailover(config.FailoverConfig{}, []Source{{Name: "crypto", Provider: crypto}})},
+		{Symbols: []string{"AAPL", "MSFT"}, Failover: NewFailover(config.FailoverConfig{}, []Source{
+			{Name: "equities", Provider: equities},
+			{Name: "fallback", Provider: fallback},
+		})},
+		{Failover: NewFailover(config.FailoverConfig{}, []Source{{Name: "other", Provider: other}})},
+	})
+
+	if err := r.Subscribe(context.Background(), []string{"BTC-USD", "aapl", "TSLA"}); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if !slices.Equal(crypto.subscribed, []string{"BTC-USD"}) {
+		t.Errorf("expected BTC-USD on the crypto route, got %v", crypto.subscribed)
+	}
+	if !slices.Equal(equities.subscribed, []string{"aapl"}) || !slices.Equal(fallback.subscribed, []string{"aapl"}) {
+		t.Errorf("expected aapl on both equity sources, got %v and %v", equities.subscribed, fallback.subscribed)
+	}
+	if !slices.Equal(other.subscribed, []string{"TSLA"}) {
+		t.Errorf("expected TSLA on the default route, got %v", other.subscribed)
+	}
+
+	routes := r.Routes()
+	if len(routes) != 3 || routes[2].Symbols[0] != "*" || routes[1].Active != "equities" {
+		t.Errorf("unexpected routes: %+v", routes)
+	}
+
+	if err := r.SetPriority(1, []string{"fallback", "equities"}); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if got := r.Routes()[1]; !slices.Equal(got.Providers, []string{"fallback", "equities"}) {
+		t.Errorf("expected reordered providers, got %v", got.Providers)
+	}
+	if err := r.SetPriority(1, []string{"equities"}); !errors.Is(err, ErrInvalidPriority) {
+		t.Errorf("expected ErrInvalidPriority, got: %v", err)
+	}
+	if err := r.SetPriority(5, nil); !errors.Is(err, ErrUnknownRoute) {
+		t.Errorf("expected ErrUnknownRoute, got: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- r.Run(ctx) }()
+
+	crypto.ticks <- Tick{Symbol: "BTC-USD", Price: 60000}
+	select {
+	case got := <-r.Ticks():
+		if got.Symbol != "BTC-USD" {
+			t.Errorf("unexpected tick: %+v", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a routed tick")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("expected no error, got: %v", err)
+	}
+}
+
+func TestFailoverSetPriority(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	f := NewFailover(config.FailoverConfig{}, []Source{
+		{Name: "primary", Provider: newFakeProvider()},
+		{Name: "secondary", Provider: newFakeProvider()},
+	})
+	f.now = func() time.Time { return now }
+	for _, s := range f.sources {
+		s.lastTick = now
+	}
+
+	if err := f.SetPriority([]string{"secondary", "primary"}); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if got := f.Active(); got != "secondary" {
+		t.Errorf("expected healthy preferred source to take over, got %s", got)
+	}
+	if err := f.SetPriority([]string{"secondary", "tertiary"}); !errors.Is(err, ErrInvalidPriority) {
+		t.Errorf("expected ErrInvalidPriority, got: %v", err)
+	}
+}
+
+func TestNewFromConfigRouting(t *testing.T) {
+	p, err := NewFromConfig("simulator", config.FailoverConfig{}, []config.RouteConfig{
+		{Symbols: []string{"*-USD"}, Providers: []string{"simulator", "simulator"}},
+	}, config.SimulatorConfig{}, nil)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	r, ok := p.(*Router)
+	if !ok {
+		t.Fatalf("expected a router, got %T", p)
+	}
+	if routes := r.Routes(); len(routes) != 2 || len(routes[0].Providers) != 2 {
+		t.Errorf("expected a configured route plus the default, got %+v", routes)
+	}
+}
+
+func TestRouterDivergences(t *testing.T) {
+	f := NewFailover(config.FailoverConfig{ReconcileTolerance: 0.01}, []Source{
+		{Name: "equities", Provider: newFakeProvider()},
+		{Name: "fallback", Provider: newFakeProvider()},
+	})
+	r := NewRouter([]Route{{Failover: f}})
+
+	f.handleTick(0, Tick{Symbol: "AAPL", Price: 200})
+	f.handleTick(1, Tick{Symbol: "AAPL", Price: 210})
+
+	select {
+	case d := <-r.Divergences():
+		if d.Provider != "fallback" || d.Reference != "equities" {
+			t.Errorf("unexpected divergence: %+v", d)
+		}
+	default:
+		t.Fatal("expected the route's divergence on the router")
+	}
+}
@@ -0,0 +1,239 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"marketflash/internal/config"
+	"marketflash/internal/ratelimit"
+)
+
+const (
+	defaultShadowTolerance = 0.001
+
+	// compareWindow is how recent the other feed's quote must be for a tick
+	// to be compared against it.
+	compareWindow = 5 * time.Second
+)
+
+// FeedReport summarises one side of a shadow comparison. Latency is the time
+// from a tick's own timestamp to its arrival here.
+type FeedReport struct {
+	Provider    string        `json:"provider"`
+	Ticks       uint64        `json:"ticks"`
+	MeanLatency time.Duration `json:"mean_latency"`
+	MaxLatency  time.Duration `json:"max_latency"`
+}
+
+// SymbolReport compares both feeds for one symbol. Diffs are relative to the
+// other feed's latest price.
+type SymbolReport struct {
+	Compared uint64  `json:"compared"`
+	Diverged uint64  `json:"diverged"`
+	MeanDiff float64 `json:"mean_diff"`
+	MaxDiff  float64 `json:"max_diff"`
+}
+
+// ShadowReport is the comparison collected since the shadow started.
+type ShadowReport struct {
+	Since     time.Time               `json:"since"`
+	Baseline  FeedReport              `json:"baseline"`
+	Candidate FeedReport              `json:"candidate"`
+	Symbols   map[string]SymbolReport `json:"symbols"`
+}
+
+type feedStats struct {
+	ticks      uint64
+	timed      uint64 // ticks carrying a timestamp
+	latencySum time.Duration
+	latencyMax time.Duration
+	quotes     map[string]quote
+}
+
+type symbolStats struct {
+	compared uint64
+	diverged uint64
+	diffSum  float64
+	diffMax  float64
+}
+
+// Shadow subscribes a baseline and a candidate provider to the same symbols
+// and compares their prices and latency, e.g. to judge whether a premium feed
+// is worth paying for. Neither feed's ticks are forwarded.
+type Shadow struct {
+	sources     [2]Source // baseline, candidate
+	tolerance   float64
+	divergences chan Divergence
+	now         func() time.Time
+
+	mu      sync.Mutex
+	since   time.Time
+	feeds   [2]*feedStats
+	symbols map[string]*symbolStats
+}
+
+// NewShadow returns a Shadow comparing candidate against baseline.
+func NewShadow(cfg config.ShadowConfig, baseline, candidate Source) *Shadow {
+	s := &Shadow{
+		sources:     [2]Source{baseline, candidate},
+		tolerance:   defaultShadowTolerance,
+		divergences: make(chan Divergence, tickBuffer),
+		now:         time.Now,
+		symbols:     make(map[string]*symbolStats),
+	}
+	if cfg.Tolerance > 0 {
+		s.tolerance = cfg.Tolerance
+	}
+	for i := range s.feeds {
+		s.feeds[i] = &feedStats{quotes: make(map[string]quote)}
+	}
+
+	return s
+}
+
+// NewShadowFromConfig returns a Shadow comparing the cfg.Provider feed
+// against a separate instance of primary, or nil when no shadow provider is
+// configured. Subscription calls wait for each provider's quota in outbound,
+// which may be nil. Consume its divergences with LogDivergences.
+func NewShadowFromConfig(primary string, cfg config.ShadowConfig, simulator config.SimulatorConfig, outbound *ratelimit.Outbound) (*Shadow, error) {
+	if cfg.Provider == "" {
+		return nil, nil
+	}
+
+	sources, err := newSources([]string{primary, cfg.Provider}, simulator, outbound)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewShadow(cfg, sources[0], sources[1]), nil
+}
+
+func (s *Shadow) Subscribe(ctx context.Context, symbols []string) error {
+	return errors.Join(
+		s.sources[0].Provider.Subscribe(ctx, symbols),
+		s.sources[1].Provider.Subscribe(ctx, symbols),
+	)
+}
+
+func (s *Shadow) Unsubscribe(ctx context.Context, symbols []string) error {
+	return errors.Join(
+		s.sources[0].Provider.Unsubscribe(ctx, symbols),
+		s.sources[1].Provider.Unsubscribe(ctx, symbols),
+	)
+}
+
+// Divergences returns ticks whose price differs from the other feed's by more
+// than the tolerance. Reports are dropped when the channel is full.
+func (s *Shadow) Divergences() <-chan Divergence {
+	return s.divergences
+}
+
+// Run compares both feeds until ctx is cancelled.
+func (s *Shadow) Run(ctx context.Context) error {
+	s.mu.Lock()
+	s.since = s.now()
+	s.mu.Unlock()
+
+	events, wait := fanIn(ctx, s.sources[:])
+
+	for {
+		select {
+		case <-ctx.Done():
+			wait()
+			return nil
+		case ev := <-events:
+			if !ev.ended {
+				s.handleTick(ev.source, ev.tick)
+			}
+		}
+	}
+}
+
+func (s *Shadow) handleTick(i int, t Tick) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	feed := s.feeds[i]
+	feed.ticks++
+	if !t.Time.IsZero() {
+		latency := max(now.Sub(t.Time), 0)
+		feed.timed++
+		feed.latencySum += latency
+		feed.latencyMax = max(feed.latencyMax, latency)
+	}
+	feed.quotes[t.Symbol] = quote{price: t.Price, at: now}
+
+	other := s.feeds[1-i].quotes[t.Symbol]
+	if other.price == 0 || now.Sub(other.at) > compareWindow {
+		return
+	}
+
+	sym := s.symbols[t.Symbol]
+	if sym == nil {
+		sym = &symbolStats{}
+		s.symbols[t.Symbol] = sym
+	}
+
+	diff := math.Abs(t.Price-other.price) / other.price
+	sym.compared++
+	sym.diffSum += diff
+	sym.diffMax = max(sym.diffMax, diff)
+
+	if diff <= s.tolerance {
+		return
+	}
+
+	sym.diverged++
+	select {
+	case s.divergences <- Divergence{
+		Symbol:         t.Symbol,
+		Provider:       s.sources[i].Name,
+		Price:          t.Price,
+		Reference:      s.sources[1-i].Name,
+		ReferencePrice: other.price,
+		At:             now,
+	}:
+	default:
+	}
+}
+
+// Report returns the comparison so far.
+func (s *Shadow) Report() ShadowReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	report := ShadowReport{
+		Since:     s.since,
+		Baseline:  s.feedReport(0),
+		Candidate: s.feedReport(1),
+		Symbols:   make(map[string]SymbolReport, len(s.symbols)),
+	}
+
+	for name, sym := range s.symbols {
+		report.Symbols[name] = SymbolReport{
+			Compared: sym.compared,
+			Diverged: sym.diverged,
+			MeanDiff: sym.diffSum / float64(sym.compared),
+			MaxDiff:  sym.diffMax,
+		}
+	}
+
+	return report
+}
+
+func (s *Shadow) feedReport(i int) FeedReport {
+	feed := s.feeds[i]
+	r := FeedReport{
+		Provider:   s.sources[i].Name,
+		Ticks:      feed.ticks,
+		MaxLatency: feed.latencyMax,
+	}
+	if feed.timed > 0 {
+		r.MeanLatency = feed.latencySum / time.Duration(feed.timed)
+	}
+	return r
+}
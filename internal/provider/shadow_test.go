@@ -0,0 +1,102 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"marketflash/internal/config"
+)
+
+func TestShadow(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	baseline, candidate := newFakeProvider(), newFakeProvider()
+
+	s := NewShadow(config.ShadowConfig{Tolerance: 0.01},
+		Source{Name: "free", Provider: baseline},
+		Source{Name: "premium", Provider: candidate})
+	s.now = func() time.Time { return now }
+
+	_ = s.Subscribe(context.Background(), []string{"AAPL"})
+	if len(baseline.subscribed) != 1 || len(candidate.subscribed) != 1 {
+		t.Fatalf("expected both feeds subscribed, got %v and %v", baseline.subscribed, candidate.subscribed)
+	}
+
+	s.handleTick(0, Tick{Symbol: "AAPL", Price: 200, Time: now.Add(-300 * time.Millisecond)})
+	s.handleTick(1, Tick{Symbol: "AAPL", Price: 201, Time: now.Add(-100 * time.Millisecond)})
+	s.handleTick(1, Tick{Symbol: "AAPL", Price: 210, Time: now.Add(-100 * time.Millisecond)})
+
+	select {
+	case d := <-s.Divergences():
+		if d.Provider != "premium" || d.Reference != "free" || d.Price != 210 {
+			t.Errorf("unexpected divergence: %+v", d)
+		}
+	default:
+		t.Fatal("expected a divergence")
+	}
+
+	// Quotes older than the compare window are not compared.
+	now = now.Add(time.Minute)
+	s.handleTick(1, Tick{Symbol: "AAPL", Price: 300})
+
+	report := s.Report()
+	if report.Baseline.Ticks != 1 || report.Baseline.MeanLatency != 300*time.Millisecond {
+		t.Errorf("unexpected baseline report: %+v", report.Baseline)
+	}
+	if report.Candidate.Ticks != 3 || report.Candidate.MaxLatency != 100*time.Millisecond {
+		t.Errorf("unexpected candidate report: %+v", report.Candidate)
+	}
+
+	aapl := report.Symbols["AAPL"]
+	if aapl.Compared != 2 || aapl.Diverged != 1 || aapl.MaxDiff != 0.05 {
+		t.Errorf("unexpected AAPL comparison: %+v", aapl)
+	}
+}
+
+func TestNewShadowFromConfig(t *testing.T) {
+	s, err := NewShadowFromConfig("simulator", config.ShadowConfig{}, config.SimulatorConfig{}, nil)
+	if err != nil || s != nil {
+		t.Fatalf("expected no shadow without a provider, got %v err=%v", s, err)
+	}
+
+	s, err = NewShadowFromConfig("simulator", config.ShadowConfig{Provider: "simulator", Tolerance: 0.01}, config.SimulatorConfig{}, nil)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if s.tolerance != 0.01 || s.sources[0].Provider == s.sources[1].Provider {
+		t.Errorf("expected separate feeds at the configured tolerance, got %+v", s)
+	}
+
+	if _, err := NewShadowFromConfig("simulator", config.ShadowConfig{Provider: "nope"}, config.SimulatorConfig{}, nil); !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("expected error %v, got: %v", ErrUnknownProvider, err)
+	}
+}
+
+func TestLogDivergences(t *testing.T) {
+	var buf bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+
+	divergences := make(chan Divergence, 1)
+	divergences <- Divergence{Symbol: "AAPL", Provider: "premium", Price: 210, Reference: "free", ReferencePrice: 200}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- LogDivergences(ctx, divergences) }()
+
+	for deadline := time.Now().Add(time.Second); len(divergences) > 0 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "symbol=AAPL provider=premium price=210 reference=free") {
+		t.Errorf("expected the divergence logged, got %q", buf.String())
+	}
+}
@@ -0,0 +1,107 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"marketflash/internal/config"
+)
+
+func TestSimulator(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.SimulatorConfig{Symbols: []string{"msft", "AAPL"}, Seed: 42}
+
+	t.Run("is reproducible with a seed", func(t *testing.T) {
+		a, b := NewSimulator(cfg), NewSimulator(cfg)
+		fixed := func() time.Time { return time.Unix(1700000000, 0) }
+		a.now, b.now = fixed, fixed
+		for range 50 {
+			if ta, tb := a.step(), b.step(); !reflect.DeepEqual(ta, tb) {
+				t.Fatalf("expected identical ticks, got %v and %v", ta, tb)
+			}
+		}
+	})
+
+	t.Run("walks from stable starting prices", func(t *testing.T) {
+		s := NewSimulator(cfg)
+		start := startingPrice("AAPL")
+
+		var last Tick
+		for range 100 {
+			ticks := s.step()
+			if len(ticks) != 2 || ticks[0].Symbol != "AAPL" || ticks[1].Symbol != "MSFT" {
+				t.Fatalf("expected ticks for AAPL and MSFT, got %v", ticks)
+			}
+			last = ticks[0]
+			if last.Price <= 0 || last.Size <= 0 {
+				t.Fatalf("expected positive price and size, got %+v", last)
+			}
+		}
+
+		// 100 ticks at 0.1% volatility should stay well within 10%.
+		if last.Price < start*0.9 || last.Price > start*1.1 {
+			t.Errorf("expected price near %.2f, got %.2f", start, last.Price)
+		}
+	})
+
+	t.Run("follows subscriptions", func(t *testing.T) {
+		s := NewSimulator(cfg)
+
+		_ = s.Unsubscribe(ctx, []string{"MSFT"})
+		_ = s.Subscribe(ctx, []string{"tsla"})
+
+		var symbols []string
+		for _, tick := range s.step() {
+			symbols = append(symbols, tick.Symbol)
+		}
+		if want := []string{"AAPL", "TSLA"}; !reflect.DeepEqual(symbols, want) {
+			t.Errorf("expected symbols %v, got %v", want, symbols)
+		}
+	})
+
+	t.Run("reconnect restarts from starting prices", func(t *testing.T) {
+		s := NewSimulator(cfg)
+		for range 10 {
+			s.step()
+		}
+
+		_ = s.Reconnect(ctx)
+		if got := s.prices["AAPL"]; got != startingPrice("AAPL") {
+			t.Errorf("expected starting price %.2f, got %.2f", startingPrice("AAPL"), got)
+		}
+	})
+
+	t.Run("streams until cancelled", func(t *testing.T) {
+		s := NewSimulator(config.SimulatorConfig{Symbols: []string{"AAPL"}, TickInterval: time.Millisecond})
+
+		ctx, cancel := context.WithCancel(ctx)
+		done := make(chan error, 1)
+		go func() { done <- s.Run(ctx) }()
+
+		select {
+		case tick := <-s.Ticks():
+			if tick.Symbol != "AAPL" {
+				t.Errorf("expected AAPL tick, got %+v", tick)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("expected a tick")
+		}
+
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("expected no error, got: %v", err)
+		}
+	})
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New("simulator", config.SimulatorConfig{}); err != nil {
+		t.Errorf("expected no error, got: %v", err)
+	}
+	if _, err := New("bloomberg", config.SimulatorConfig{}); !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("expected error %v, got: %v", ErrUnknownProvider, err)
+	}
+}
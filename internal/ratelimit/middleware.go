@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+
+	"marketflash/internal/auth"
+	"marketflash/internal/validate"
+)
+
+// KeyFunc identifies the client a request is counted against.
+type KeyFunc func(r *http.Request) string
+
+// ClientKey keys requests by the authenticated API key, falling back to the
+// remote IP for anonymous requests. Credentials in request headers are never
+// used directly: they are unverified, so a client could escape its limit by
+// sending a new value per request. Mount Middleware behind
+// auth.Manager.Middleware for requests to be counted per key.
+func ClientKey(r *http.Request) string {
+	if p, ok := auth.PrincipalFrom(r.Context()); ok {
+		return "key:" + p.KeyID
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	return "ip:" + host
+}
+
+// Middleware rejects requests over the limit with 429 Too Many Requests and a
+// Retry-After header in whole seconds. A nil limiter lets every request
+// through.
+func Middleware(limiter *Keyed, key KeyFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if limiter == nil {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ok, retryAfter := limiter.Allow(key(r))
+			if !ok {
+				secs := max(int(math.Ceil(retryAfter.Seconds())), 1)
+				w.Header().Set("Retry-After", strconv.Itoa(secs))
+				validate.WriteProblem(w, validate.Problem{Status: http.StatusTooManyRequests, Detail: "rate limit exceeded"})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"marketflash/internal/auth"
+)
+
+func TestMiddleware(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	limiter := newKeyed(0.5, 1, clock.now)
+
+	handler := Middleware(limiter, ClientKey)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	do := func(remoteAddr, keyID string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/v1/quotes/AAPL", nil)
+		req.RemoteAddr = remoteAddr
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		if keyID != "" {
+			req = req.WithContext(auth.WithPrincipal(req.Context(), auth.Principal{KeyID: keyID}))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do("10.0.0.1:1234", "", nil); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	rec := do("10.0.0.1:5678", "", nil)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 for same IP, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("expected Retry-After 2, got %q", got)
+	}
+
+	for _, header := range []http.Header{
+		{"X-Api-Key": {"random-1"}},
+		{"Authorization": {"Bearer random-2"}},
+	} {
+		if rec := do("10.0.0.1:1234", "", header); rec.Code != http.StatusTooManyRequests {
+			t.Errorf("expected unauthenticated credentials %v not to escape the IP limit, got %d", header, rec.Code)
+		}
+	}
+	if len(limiter.buckets) != 1 {
+		t.Errorf("expected one bucket, got %d", len(limiter.buckets))
+	}
+
+	if rec := do("10.0.0.1:1234", "key-1", nil); rec.Code != http.StatusOK {
+		t.Errorf("expected API key to be limited separately from IP, got %d", rec.Code)
+	}
+	if rec := do("10.0.0.2:1234", "key-1", nil); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("expected API key limit to apply across IPs, got %d", rec.Code)
+	}
+}
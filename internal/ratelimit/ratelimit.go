@@ -0,0 +1,223 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"marketflash/internal/config"
+)
+
+// Bucket is a token bucket refilled at a fixed rate up to its burst size.
+// It is safe for concurrent use.
+type Bucket struct {
+	mu     sync.Mutex
+	rate   float64 // tokens per second
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+// NewBucket returns a full bucket refilled at rate tokens per second. Burst
+// values below 1 are raised to 1 so at least one request can pass.
+func NewBucket(rate float64, burst int) *Bucket {
+	return newBucket(rate, burst, time.Now)
+}
+
+func newBucket(rate float64, burst int, now func() time.Time) *Bucket {
+	b := float64(max(burst, 1))
+
+	return &Bucket{
+		rate:   rate,
+		burst:  b,
+		tokens: b,
+		last:   now(),
+		now:    now,
+	}
+}
+
+// Allow takes a token if one is available. Otherwise it reports how long the
+// caller should wait before a token will be.
+func (b *Bucket) Allow() (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill()
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	return false, b.waitFor(1 - b.tokens)
+}
+
+// Wait blocks until a token is available or ctx is done. Waiters queue in
+// arrival order: each one reserves a token up front, driving the balance
+// negative, and sleeps until the refill covers its reservation. A cancelled
+// waiter returns its reservation.
+func (b *Bucket) Wait(ctx context.Context) error {
+	b.mu.Lock()
+	b.refill()
+	b.tokens--
+	wait := b.waitFor(-b.tokens)
+	b.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		b.mu.Lock()
+		b.tokens = min(b.tokens+1, b.burst)
+		b.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// full reports whether the bucket has refilled completely, meaning it holds
+// no state worth keeping.
+func (b *Bucket) full() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill()
+
+	return b.tokens >= b.burst
+}
+
+func (b *Bucket) refill() {
+	now := b.now()
+	elapsed := now.Sub(b.last).Seconds()
+	b.last = now
+
+	if elapsed > 0 {
+		b.tokens = min(b.tokens+elapsed*b.rate, b.burst)
+	}
+}
+
+func (b *Bucket) waitFor(tokens float64) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+	if b.rate <= 0 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(tokens / b.rate * float64(time.Second))
+}
+
+// Keyed keeps one bucket per key, such as an API key or client IP. Buckets
+// that have refilled completely are dropped periodically so idle clients
+// don't accumulate.
+type Keyed struct {
+	mu        sync.Mutex
+	rate      float64
+	burst     int
+	buckets   map[string]*Bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+const sweepInterval = time.Minute
+
+// NewKeyed returns a limiter allowing rate requests per second with bursts of
+// burst per key.
+func NewKeyed(rate float64, burst int) *Keyed {
+	return newKeyed(rate, burst, time.Now)
+}
+
+func newKeyed(rate float64, burst int, now func() time.Time) *Keyed {
+	return &Keyed{
+		rate:      rate,
+		burst:     burst,
+		buckets:   make(map[string]*Bucket),
+		lastSweep: now(),
+		now:       now,
+	}
+}
+
+// Allow takes a token from the bucket for key. See Bucket.Allow.
+func (k *Keyed) Allow(key string) (bool, time.Duration) {
+	return k.bucket(key).Allow()
+}
+
+func (k *Keyed) bucket(key string) *Bucket {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if now := k.now(); now.Sub(k.lastSweep) >= sweepInterval {
+		for key, b := range k.buckets {
+			if b.full() {
+				delete(k.buckets, key)
+			}
+		}
+		k.lastSweep = now
+	}
+
+	b, ok := k.buckets[key]
+	if !ok {
+		b = newBucket(k.rate, k.burst, k.now)
+		k.buckets[key] = b
+	}
+
+	return b
+}
+
+// Outbound holds one bucket per upstream provider so calls respect the
+// provider's quota, e.g. 5 requests per minute on the Alpha Vantage free
+// tier. Providers without a configured limit are not throttled.
+type Outbound struct {
+	buckets map[string]*Bucket
+}
+
+// NewOutbound returns an empty outbound limiter.
+func NewOutbound() *Outbound {
+	return &Outbound{buckets: make(map[string]*Bucket)}
+}
+
+// Limit sets the quota for provider. It must be called before the limiter is
+// shared between goroutines.
+func (o *Outbound) Limit(provider string, rate float64, burst int) {
+	o.buckets[provider] = NewBucket(rate, burst)
+}
+
+// Limits reports whether provider has a quota set.
+func (o *Outbound) Limits(provider string) bool {
+	_, ok := o.buckets[provider]
+	return ok
+}
+
+// Wait blocks until provider's quota allows another call or ctx is done.
+func (o *Outbound) Wait(ctx context.Context, provider string) error {
+	b, ok := o.buckets[provider]
+	if !ok {
+		return ctx.Err()
+	}
+	return b.Wait(ctx)
+}
+
+// FromConfig returns the inbound limiter, nil when cfg.Inbound is disabled,
+// and the outbound limiter for every provider with a limit enabled.
+func FromConfig(cfg config.RateLimitConfig) (*Keyed, *Outbound) {
+	var inbound *Keyed
+	if cfg.Inbound.Enabled() {
+		inbound = NewKeyed(cfg.Inbound.Rate(), cfg.Inbound.Burst)
+	}
+
+	outbound := NewOutbound()
+	for name, rc := range cfg.Providers {
+		if rc.Enabled() {
+			outbound.Limit(name, rc.Rate(), rc.Burst)
+		}
+	}
+
+	return inbound, outbound
+}
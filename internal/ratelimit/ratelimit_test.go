@@ -0,0 +1,157 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"marketflash/internal/config"
+)
+
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	return c.t
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.t = c.t.Add(d)
+}
+
+func TestBucketAllow(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	b := newBucket(2, 3, clock.now)
+
+	for i := range 3 {
+		if ok, _ := b.Allow(); !ok {
+			t.Fatalf("expected request %d within burst to pass", i)
+		}
+	}
+
+	ok, retryAfter := b.Allow()
+	if ok {
+		t.Fatalf("expected request over burst to be rejected")
+	}
+	if retryAfter != 500*time.Millisecond {
+		t.Errorf("expected retry after 500ms, got %s", retryAfter)
+	}
+
+	clock.advance(500 * time.Millisecond)
+	if ok, _ := b.Allow(); !ok {
+		t.Errorf("expected request after refill to pass")
+	}
+
+	clock.advance(time.Hour)
+	for i := range 3 {
+		if ok, _ := b.Allow(); !ok {
+			t.Fatalf("expected refill to cap at burst, request %d rejected", i)
+		}
+	}
+	if ok, _ := b.Allow(); ok {
+		t.Errorf("expected refill to cap at burst")
+	}
+}
+
+func TestBucketWait(t *testing.T) {
+	t.Run("waits for refill", func(t *testing.T) {
+		b := NewBucket(100, 1)
+
+		start := time.Now()
+		for range 3 {
+			if err := b.Wait(context.Background()); err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+		}
+
+		if elapsed := time.Since(start); elapsed < 15*time.Millisecond {
+			t.Errorf("expected queued waits to take at least 20ms, took %s", elapsed)
+		}
+	})
+
+	t.Run("cancelled wait returns reservation", func(t *testing.T) {
+		clock := &fakeClock{t: time.Unix(0, 0)}
+		b := newBucket(0.001, 1, clock.now)
+		b.Allow()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		if err := b.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected error %v, got: %v", context.DeadlineExceeded, err)
+		}
+		if b.tokens != 0 {
+			t.Errorf("expected reservation to be returned, tokens = %v", b.tokens)
+		}
+	})
+}
+
+func TestKeyed(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	k := newKeyed(1, 1, clock.now)
+
+	if ok, _ := k.Allow("a"); !ok {
+		t.Fatalf("expected first request for a to pass")
+	}
+	if ok, _ := k.Allow("a"); ok {
+		t.Errorf("expected second request for a to be rejected")
+	}
+	if ok, _ := k.Allow("b"); !ok {
+		t.Errorf("expected keys to be limited independently")
+	}
+
+	clock.advance(sweepInterval)
+	k.Allow("c")
+
+	if _, ok := k.buckets["a"]; ok {
+		t.Errorf("expected idle bucket for a to be swept")
+	}
+	if _, ok := k.buckets["c"]; !ok {
+		t.Errorf("expected bucket for c to be kept")
+	}
+}
+
+func TestOutbound(t *testing.T) {
+	o := NewOutbound()
+	o.Limit("alpha_vantage", 5.0/60, 1)
+
+	ctx := context.Background()
+	if err := o.Wait(ctx, "alpha_vantage"); err != nil {
+		t.Fatalf("expected first call to pass, got: %v", err)
+	}
+	if err := o.Wait(ctx, "unlimited"); err != nil {
+		t.Fatalf("expected unlimited provider to pass, got: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+
+	if err := o.Wait(ctx, "alpha_vantage"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected second call to wait past the deadline, got: %v", err)
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	inbound, outbound := FromConfig(config.RateLimitConfig{
+		Providers: map[string]config.RateConfig{
+			"alpha_vantage": {Requests: 5, Per: time.Minute},
+			"polygon":       {},
+		},
+	})
+	if inbound != nil {
+		t.Errorf("expected no inbound limiter when disabled, got %+v", inbound)
+	}
+	if !outbound.Limits("alpha_vantage") || outbound.Limits("polygon") {
+		t.Errorf("expected only alpha_vantage to be limited, got %+v", outbound.buckets)
+	}
+
+	inbound, _ = FromConfig(config.RateLimitConfig{Inbound: config.RateConfig{Requests: 1, Per: time.Second}})
+	if ok, _ := inbound.Allow("ip:10.0.0.1"); !ok {
+		t.Error("expected the first inbound request to pass")
+	}
+	if ok, _ := inbound.Allow("ip:10.0.0.1"); ok {
+		t.Error("expected the second inbound request to be limited")
+	}
+}
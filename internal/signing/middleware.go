@@ -0,0 +1,81 @@
+package signing
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+const (
+	SignatureHeader = "X-Marketflash-Signature"
+	KeyIDHeader     = "X-Marketflash-Key-Id"
+
+	// KeyPath is where the public key is published.
+	KeyPath = "/.well-known/marketflash-signing-key"
+)
+
+// publishedKey is the document served at KeyPath.
+type publishedKey struct {
+	KeyID     string `json:"key_id"`
+	Algorithm string `json:"algorithm"`
+	PublicKey string `json:"public_key"`
+}
+
+// Middleware signs every response body, setting SignatureHeader to the
+// base64 Ed25519 signature and KeyIDHeader to the signing key's ID. The body
+// is buffered to be signed, so it must not wrap streaming endpoints.
+func (s *Signer) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &bufferedResponse{header: w.Header(), status: http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		body := rec.body.Bytes()
+		w.Header().Set(SignatureHeader, s.Sign(body))
+		w.Header().Set(KeyIDHeader, s.keyID)
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(rec.status)
+		_, _ = w.Write(body)
+	})
+}
+
+// KeyHandler serves the public key document for KeyPath.
+func (s *Signer) KeyHandler() http.Handler {
+	doc, _ := json.Marshal(publishedKey{
+		KeyID:     s.keyID,
+		Algorithm: "ed25519",
+		PublicKey: base64.StdEncoding.EncodeToString(s.PublicKey()),
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+		_, _ = w.Write(doc)
+	})
+}
+
+// bufferedResponse holds a response until it can be signed.
+type bufferedResponse struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	if !b.wroteHeader {
+		b.status = status
+		b.wroteHeader = true
+	}
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	b.wroteHeader = true
+	return b.body.Write(p)
+}
@@ -0,0 +1,60 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	s := newTestSigner(t)
+
+	handler := s.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		io.WriteString(w, `{"symbol":"AAPL",`)
+		io.WriteString(w, `"price":190.5}`)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/quotes/AAPL", nil))
+
+	if rec.Code != http.StatusAccepted {
+		t.Errorf("expected status to pass through, got %d", rec.Code)
+	}
+	if got := rec.Header().Get(KeyIDHeader); got != s.KeyID() {
+		t.Errorf("expected key id %s, got %q", s.KeyID(), got)
+	}
+
+	body := rec.Body.Bytes()
+	if string(body) != `{"symbol":"AAPL","price":190.5}` {
+		t.Errorf("unexpected body %s", body)
+	}
+	if err := Verify(s.PublicKey(), body, rec.Header().Get(SignatureHeader)); err != nil {
+		t.Errorf("expected valid signature, got: %v", err)
+	}
+}
+
+func TestKeyHandler(t *testing.T) {
+	s := newTestSigner(t)
+
+	rec := httptest.NewRecorder()
+	s.KeyHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, KeyPath, nil))
+
+	var doc publishedKey
+	if err := json.NewDecoder(rec.Body).Decode(&doc); err != nil {
+		t.Fatalf("failed to decode key document: %v", err)
+	}
+
+	pub, err := base64.StdEncoding.DecodeString(doc.PublicKey)
+	if err != nil {
+		t.Fatalf("failed to decode public key: %v", err)
+	}
+	if doc.KeyID != s.KeyID() || doc.Algorithm != "ed25519" || !s.PublicKey().Equal(ed25519.PublicKey(pub)) {
+		t.Errorf("unexpected key document %+v", doc)
+	}
+}
@@ -0,0 +1,110 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+var (
+	ErrReadKey          = errors.New("unable to read signing key")
+	ErrInvalidKey       = errors.New("signing key must be a PKCS#8 PEM Ed25519 private key")
+	ErrInvalidSignature = errors.New("signature verification failed")
+)
+
+// SignatureExt is appended to a file's name for its detached signature.
+const SignatureExt = ".sig"
+
+// Signer produces detached Ed25519 signatures with the instance key.
+type Signer struct {
+	key   ed25519.PrivateKey
+	keyID string
+}
+
+// NewSigner returns a Signer for key.
+func NewSigner(key ed25519.PrivateKey) *Signer {
+	return &Signer{key: key, keyID: KeyID(key.Public().(ed25519.PublicKey))}
+}
+
+// LoadSigner reads a PKCS#8 PEM Ed25519 private key from path.
+func LoadSigner(path string) (*Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrReadKey, err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, fmt.Errorf("%w: %s has no PRIVATE KEY block", ErrInvalidKey, path)
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidKey, err)
+	}
+
+	key, ok := parsed.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: got %T", ErrInvalidKey, parsed)
+	}
+
+	return NewSigner(key), nil
+}
+
+// KeyID is a short fingerprint of pub that lets verifiers pick the right key
+// after a rotation.
+func KeyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+// KeyID returns the fingerprint of the signer's public key.
+func (s *Signer) KeyID() string {
+	return s.keyID
+}
+
+// PublicKey returns the key verifiers need.
+func (s *Signer) PublicKey() ed25519.PublicKey {
+	return s.key.Public().(ed25519.PublicKey)
+}
+
+// Sign returns the base64 signature of data.
+func (s *Signer) Sign(data []byte) string {
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(s.key, data))
+}
+
+// SignFile writes the detached signature of the file at path to
+// path+SignatureExt.
+func (s *Signer) SignFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading %s for signing: %w", path, err)
+	}
+
+	sig := s.keyID + " " + s.Sign(data) + "\n"
+	if err := os.WriteFile(path+SignatureExt, []byte(sig), 0o644); err != nil {
+		return fmt.Errorf("writing signature for %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// Verify checks a base64 signature of data against pub.
+func Verify(pub ed25519.PublicKey, data []byte, signature string) error {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidSignature, err)
+	}
+
+	if !ed25519.Verify(pub, data, sig) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
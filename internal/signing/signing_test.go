@@ -0,0 +1,99 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeKeyFile(t *testing.T, key any) string {
+	t.Helper()
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "signing.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+
+	return path
+}
+
+func newTestSigner(t *testing.T) *Signer {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	return NewSigner(key)
+}
+
+func TestLoadSigner(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	s, err := LoadSigner(writeKeyFile(t, key))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if !s.PublicKey().Equal(key.Public()) {
+		t.Errorf("expected loaded key to match")
+	}
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("failed to generate rsa key: %v", err)
+	}
+	if _, err := LoadSigner(writeKeyFile(t, rsaKey)); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("expected error %v for rsa key, got: %v", ErrInvalidKey, err)
+	}
+
+	if _, err := LoadSigner(filepath.Join(t.TempDir(), "missing.pem")); !errors.Is(err, ErrReadKey) {
+		t.Errorf("expected error %v, got: %v", ErrReadKey, err)
+	}
+}
+
+func TestSignFile(t *testing.T) {
+	s := newTestSigner(t)
+
+	path := filepath.Join(t.TempDir(), "candles-2024-01-02.csv")
+	data := []byte("timestamp,open,high,low,close\n")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("failed to write archive: %v", err)
+	}
+
+	if err := s.SignFile(path); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	raw, err := os.ReadFile(path + SignatureExt)
+	if err != nil {
+		t.Fatalf("expected signature file, got: %v", err)
+	}
+
+	keyID, sig, ok := strings.Cut(strings.TrimSpace(string(raw)), " ")
+	if !ok || keyID != s.KeyID() {
+		t.Fatalf("expected signature file to start with key id %s, got %q", s.KeyID(), raw)
+	}
+	if err := Verify(s.PublicKey(), data, sig); err != nil {
+		t.Errorf("expected valid signature, got: %v", err)
+	}
+	if err := Verify(s.PublicKey(), append(data, 'x'), sig); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected tampered data to fail verification, got: %v", err)
+	}
+}
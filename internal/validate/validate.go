@@ -0,0 +1,255 @@
+package validate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrInvalidBody = errors.New("invalid request body")
+	ErrNotStruct   = errors.New("validate: value is not a struct")
+)
+
+// FieldError reports one invalid field. Field is the JSON path, e.g.
+// "symbols[2]".
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Errors lists every invalid field of a payload.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, f := range e {
+		msgs[i] = f.Field + ": " + f.Message
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Validator is implemented by payloads with checks that tags cannot express.
+// Validate runs after the tag rules and returns errors for fields relative to
+// the value itself.
+type Validator interface {
+	Validate() Errors
+}
+
+// Struct checks the `validate` tags of v, a struct or pointer to one, and
+// returns Errors listing every failure, or nil. Rules are comma-separated:
+//
+//	required   not the zero value; strings must not be blank
+//	min=N      strings have at least N characters, slices N elements, and
+//	           numbers are at least N
+//	max=N      the upper bound counterpart of min
+//	oneof=a b  the value is one of the space-separated options
+//
+// Nested structs, non-nil pointers to them, and slices of either are checked
+// too. A nil pointer or a value that is not a struct returns ErrNotStruct.
+// Unknown rules panic, as they are programming errors.
+func Struct(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer && rv.IsNil() {
+		return fmt.Errorf("%w: nil %T", ErrNotStruct, v)
+	}
+	rv = reflect.Indirect(rv)
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("%w: %T", ErrNotStruct, v)
+	}
+
+	var errs Errors
+	checkStruct(rv, "", &errs)
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+func checkStruct(v reflect.Value, prefix string, errs *Errors) {
+	t := v.Type()
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name := fieldName(f)
+		if name == "-" {
+			continue
+		}
+		field := v.Field(i)
+		path := prefix + name
+
+		if tag := f.Tag.Get("validate"); tag != "" {
+			for _, rule := range strings.Split(tag, ",") {
+				if msg := check(field, rule); msg != "" {
+					*errs = append(*errs, FieldError{Field: path, Message: msg})
+				}
+			}
+		}
+
+		if field.Kind() == reflect.Slice {
+			for j := range field.Len() {
+				checkNested(field.Index(j), fmt.Sprintf("%s[%d].", path, j), errs)
+			}
+		} else {
+			checkNested(field, path+".", errs)
+		}
+	}
+
+	if v.CanAddr() {
+		v = v.Addr()
+	}
+	if val, ok := v.Interface().(Validator); ok {
+		for _, fe := range val.Validate() {
+			fe.Field = prefix + fe.Field
+			*errs = append(*errs, fe)
+		}
+	}
+}
+
+// checkNested checks v when it is a struct or a non-nil pointer to one.
+func checkNested(v reflect.Value, prefix string, errs *Errors) {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Struct {
+		checkStruct(v, prefix, errs)
+	}
+}
+
+// check returns why v breaks rule, or "" when it holds.
+func check(v reflect.Value, rule string) string {
+	name, param, _ := strings.Cut(rule, "=")
+
+	switch name {
+	case "required":
+		if v.IsZero() || (v.Kind() == reflect.String && strings.TrimSpace(v.String()) == "") {
+			return "is required"
+		}
+	case "min", "max":
+		n, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			panic(fmt.Sprintf("validate: bad %s parameter %q", name, param))
+		}
+		size, unit := measure(v)
+		if n == 1 {
+			unit = strings.TrimSuffix(unit, "s")
+		}
+		if name == "min" && size < n {
+			return fmt.Sprintf("must be at least %s%s", param, unit)
+		}
+		if name == "max" && size > n {
+			return fmt.Sprintf("must be at most %s%s", param, unit)
+		}
+	case "oneof":
+		options := strings.Fields(param)
+		value := fmt.Sprint(v.Interface())
+		if !v.IsZero() && !slices.Contains(options, value) {
+			return "must be one of: " + strings.Join(options, ", ")
+		}
+	default:
+		panic(fmt.Sprintf("validate: unknown rule %q", rule))
+	}
+
+	return ""
+}
+
+// measure returns what min and max compare for v, and the unit to name.
+func measure(v reflect.Value) (float64, string) {
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), " characters"
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(v.Len()), " items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), ""
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), ""
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), ""
+	default:
+		panic(fmt.Sprintf("validate: min and max do not apply to %s", v.Kind()))
+	}
+}
+
+func fieldName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+// Decode reads r's JSON body into v and validates it with Struct. Malformed
+// bodies return an error wrapping ErrInvalidBody; failed rules return Errors.
+func Decode(r *http.Request, v any) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidBody, err)
+	}
+	return Struct(v)
+}
+
+// Problem is an RFC 9457 problem details document.
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	Errors Errors `json:"errors,omitempty"`
+
+	// MaxBytes and MaxDepth are extension members naming the body size or
+	// JSON nesting limit a request exceeded.
+	MaxBytes int64 `json:"max_bytes,omitempty"`
+	MaxDepth int   `json:"max_depth,omitempty"`
+}
+
+// WriteProblem writes p as application/problem+json.
+func WriteProblem(w http.ResponseWriter, p Problem) {
+	if p.Type == "" {
+		p.Type = "about:blank"
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
+}
+
+// WriteStatus writes err as a problem with status. Server errors are logged
+// instead of written, as they may reveal internals, and get a generic detail.
+func WriteStatus(w http.ResponseWriter, status int, err error) {
+	detail := err.Error()
+	if status >= http.StatusInternalServerError {
+		slog.Error("request failed", "status", status, "error", err)
+		detail = "the request could not be completed"
+	}
+	WriteProblem(w, Problem{Status: status, Detail: detail})
+}
+
+// WriteError writes an error from Decode as a 400 problem, listing the
+// invalid fields when there are any.
+func WriteError(w http.ResponseWriter, err error) {
+	p := Problem{Status: http.StatusBadRequest, Detail: err.Error()}
+
+	var fields Errors
+	if errors.As(err, &fields) {
+		p.Detail = "request body failed validation"
+		p.Errors = fields
+	}
+
+	WriteProblem(w, p)
+}
@@ -0,0 +1,170 @@
+package validate
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type leg struct {
+	Side     string  `json:"side" validate:"required,oneof=buy sell"`
+	Quantity float64 `json:"quantity" validate:"min=1"`
+}
+
+type order struct {
+	Name  string   `json:"name" validate:"required,max=5"`
+	Tags  []string `json:"tags" validate:"max=2"`
+	Legs  []leg    `json:"legs" validate:"min=1"`
+	Hedge *leg     `json:"hedge"`
+	Notes string   `json:"-"`
+}
+
+func (o order) Validate() Errors {
+	if o.Name == "bad" {
+		return Errors{{Field: "name", Message: "is reserved"}}
+	}
+	return nil
+}
+
+func TestStruct(t *testing.T) {
+	tests := []struct {
+		name string
+		in   order
+		want Errors
+	}{
+		{
+			name: "valid",
+			in:   order{Name: "ok", Legs: []leg{{Side: "buy", Quantity: 1}}},
+		},
+		{
+			name: "every rule",
+			in: order{
+				Name: "   ",
+				Tags: []string{"a", "b", "c"},
+				Legs: []leg{{Side: "hold", Quantity: 0.5}, {}},
+			},
+			want: Errors{
+				{Field: "name", Message: "is required"},
+				{Field: "tags", Message: "must be at most 2 items"},
+				{Field: "legs[0].side", Message: "must be one of: buy, sell"},
+				{Field: "legs[0].quantity", Message: "must be at least 1"},
+				{Field: "legs[1].side", Message: "is required"},
+				{Field: "legs[1].quantity", Message: "must be at least 1"},
+			},
+		},
+		{
+			name: "pointer field",
+			in:   order{Name: "ok", Legs: []leg{{Side: "buy", Quantity: 1}}, Hedge: &leg{Side: "sell"}},
+			want: Errors{
+				{Field: "hedge.quantity", Message: "must be at least 1"},
+			},
+		},
+		{
+			name: "length and custom validator",
+			in:   order{Name: "bad"},
+			want: Errors{
+				{Field: "legs", Message: "must be at least 1 item"},
+				{Field: "name", Message: "is reserved"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Struct(&tt.in)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("expected no error, got: %v", err)
+				}
+				return
+			}
+
+			var got Errors
+			if !errors.As(err, &got) {
+				t.Fatalf("expected Errors, got: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestStructNotStruct(t *testing.T) {
+	for _, v := range []any{(*order)(nil), nil, "order"} {
+		if err := Struct(v); !errors.Is(err, ErrNotStruct) {
+			t.Errorf("expected error %v for %#v, got: %v", ErrNotStruct, v, err)
+		}
+	}
+}
+
+func TestDecodeAndWriteError(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantFields int
+	}{
+		{name: "malformed", body: `{"name":`},
+		{name: "invalid", body: `{"name":"toolong","legs":[]}`, wantFields: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+
+			var o order
+			err := Decode(req, &o)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if tt.wantFields == 0 && !errors.Is(err, ErrInvalidBody) {
+				t.Errorf("expected ErrInvalidBody, got: %v", err)
+			}
+
+			rec := httptest.NewRecorder()
+			WriteError(rec, err)
+
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d", rec.Code)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "application/problem+json" {
+				t.Errorf("expected problem+json, got %q", ct)
+			}
+
+			var p Problem
+			if err := json.NewDecoder(rec.Body).Decode(&p); err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if p.Type != "about:blank" || p.Title != "Bad Request" || p.Status != http.StatusBadRequest {
+				t.Errorf("unexpected problem: %+v", p)
+			}
+			if len(p.Errors) != tt.wantFields {
+				t.Errorf("expected %d field errors, got %v", tt.wantFields, p.Errors)
+			}
+		})
+	}
+}
+
+func TestWriteStatusHidesServerErrors(t *testing.T) {
+	for _, tt := range []struct {
+		status     int
+		wantDetail bool
+	}{
+		{status: http.StatusNotFound, wantDetail: true},
+		{status: http.StatusInternalServerError},
+		{status: http.StatusBadGateway},
+	} {
+		rec := httptest.NewRecorder()
+		WriteStatus(rec, tt.status, errors.New("dial tcp 10.0.0.7:5432: refused"))
+
+		var p Problem
+		if err := json.NewDecoder(rec.Body).Decode(&p); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if got := strings.Contains(p.Detail, "10.0.0.7"); got != tt.wantDetail {
+			t.Errorf("expected detail shown %v for %d, got %q", tt.wantDetail, tt.status, p.Detail)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package watchlist
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"marketflash/internal/auth"
+	"marketflash/internal/validate"
+)
+
+type watchlistRequest struct {
+	Name    string   `json:"name" validate:"required,max=100"`
+	Symbols []string `json:"symbols" validate:"max=500"`
+}
+
+func (req watchlistRequest) Validate() validate.Errors {
+	var errs validate.Errors
+	for i, sym := range req.Symbols {
+		if !symbolPattern.MatchString(strings.ToUpper(strings.TrimSpace(sym))) {
+			errs = append(errs, validate.FieldError{Field: fmt.Sprintf("symbols[%d]", i), Message: "must be a ticker symbol"})
+		}
+	}
+	return errs
+}
+
+// Handler serves watchlist CRUD under /v1/watchlists for the authenticated
+// API key:
+//
+//	GET    /v1/watchlists       list the caller's watchlists
+//	POST   /v1/watchlists       create a watchlist
+//	GET    /v1/watchlists/{id}  fetch a watchlist
+//	PUT    /v1/watchlists/{id}  replace name and symbols
+//	DELETE /v1/watchlists/{id}  delete a watchlist
+//
+// It must be mounted behind auth.Manager.Middleware.
+func Handler(svc *Service) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /v1/watchlists", func(w http.ResponseWriter, r *http.Request) {
+		lists, err := svc.List(r.Context(), owner(r))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if lists == nil {
+			lists = []Watchlist{}
+		}
+		writeJSON(w, http.StatusOK, lists)
+	})
+
+	mux.HandleFunc("POST /v1/watchlists", func(w http.ResponseWriter, r *http.Request) {
+		var req watchlistRequest
+		if err := validate.Decode(r, &req); err != nil {
+			validate.WriteError(w, err)
+			return
+		}
+
+		list, err := svc.Create(r.Context(), owner(r), req.Name, req.Symbols)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, list)
+	})
+
+	mux.HandleFunc("GET /v1/watchlists/{id}", func(w http.ResponseWriter, r *http.Request) {
+		list, err := svc.Get(r.Context(), owner(r), r.PathValue("id"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, list)
+	})
+
+	mux.HandleFunc("PUT /v1/watchlists/{id}", func(w http.ResponseWriter, r *http.Request) {
+		var req watchlistRequest
+		if err := validate.Decode(r, &req); err != nil {
+			validate.WriteError(w, err)
+			return
+		}
+
+		list, err := svc.Update(r.Context(), owner(r), r.PathValue("id"), req.Name, req.Symbols)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, list)
+	})
+
+	mux.HandleFunc("DELETE /v1/watchlists/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if err := svc.Delete(r.Context(), owner(r), r.PathValue("id")); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	return auth.RequireScope(auth.ScopeReadQuotes)(mux)
+}
+
+func owner(r *http.Request) string {
+	p, _ := auth.PrincipalFrom(r.Context())
+	return p.KeyID
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrDuplicateName):
+		status = http.StatusConflict
+	case errors.Is(err, ErrMissingName), errors.Is(err, ErrInvalidSymbol):
+		status = http.StatusBadRequest
+	}
+
+	validate.WriteStatus(w, status, err)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,76 @@
+package watchlist
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"marketflash/internal/auth"
+)
+
+func TestHandler(t *testing.T) {
+	ctx := context.Background()
+	keys := auth.NewManager(auth.NewMemoryStore(), "")
+
+	alice, _, err := keys.Issue(ctx, "alice", []auth.Scope{auth.ScopeReadQuotes})
+	if err != nil {
+		t.Fatalf("failed to issue key: %v", err)
+	}
+	bob, _, err := keys.Issue(ctx, "bob", []auth.Scope{auth.ScopeReadQuotes})
+	if err != nil {
+		t.Fatalf("failed to issue key: %v", err)
+	}
+
+	handler := keys.Middleware(Handler(NewService(NewMemoryStore(), nil)))
+
+	do := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("X-API-Key", token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do(http.MethodPost, "/v1/watchlists", alice, `{"name":"tech","symbols":["aapl","msft"]}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body)
+	}
+
+	var created Watchlist
+	if err := json.NewDecoder(rec.Body).Decode(&created); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	rec = do(http.MethodPost, "/v1/watchlists", alice, `{"name":"tech"}`)
+	if rec.Code != http.StatusConflict || rec.Header().Get("Content-Type") != "application/problem+json" {
+		t.Errorf("expected a 409 problem for duplicate name, got %d: %s", rec.Code, rec.Body)
+	}
+	rec = do(http.MethodPost, "/v1/watchlists", alice, `{"name":"x","symbols":["AAPL","bad symbol"]}`)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `"field":"symbols[1]"`) {
+		t.Errorf("expected 400 naming the invalid symbol, got %d: %s", rec.Code, rec.Body)
+	}
+
+	if rec := do(http.MethodGet, "/v1/watchlists/"+created.ID, bob, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for another key's watchlist, got %d", rec.Code)
+	}
+
+	rec = do(http.MethodGet, "/v1/watchlists", bob, "")
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != "[]" {
+		t.Errorf("expected empty list for bob, got %d: %s", rec.Code, rec.Body)
+	}
+
+	rec = do(http.MethodPut, "/v1/watchlists/"+created.ID, alice, `{"name":"tech","symbols":["NVDA"]}`)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "NVDA") {
+		t.Errorf("expected update to succeed, got %d: %s", rec.Code, rec.Body)
+	}
+
+	if rec := do(http.MethodDelete, "/v1/watchlists/"+created.ID, alice, ""); rec.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/v1/watchlists/"+created.ID, alice, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 after delete, got %d", rec.Code)
+	}
+}
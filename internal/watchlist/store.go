@@ -0,0 +1,135 @@
+package watchlist
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Store persists watchlists. Lookups are always scoped to an owner so one API
+// key can never read or modify another's lists.
+type Store interface {
+	Create(ctx context.Context, w Watchlist) error
+	Get(ctx context.Context, owner, id string) (Watchlist, error)
+	List(ctx context.Context, owner string) ([]Watchlist, error)
+	Update(ctx context.Context, w Watchlist) error
+	Delete(ctx context.Context, owner, id string) error
+	// Symbols returns the sorted union of symbols across all watchlists.
+	Symbols(ctx context.Context) ([]string, error)
+}
+
+// MemoryStore is an in-process Store, used in tests and development.
+type MemoryStore struct {
+	mu    sync.RWMutex
+	lists map[string]Watchlist // by ID
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{lists: make(map[string]Watchlist)}
+}
+
+func (s *MemoryStore) Create(_ context.Context, w Watchlist) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.nameTaken(w) {
+		return ErrDuplicateName
+	}
+
+	s.lists[w.ID] = clone(w)
+
+	return nil
+}
+
+func (s *MemoryStore) Get(_ context.Context, owner, id string) (Watchlist, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	w, ok := s.lists[id]
+	if !ok || w.Owner != owner {
+		return Watchlist{}, ErrNotFound
+	}
+
+	return clone(w), nil
+}
+
+func (s *MemoryStore) List(_ context.Context, owner string) ([]Watchlist, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []Watchlist
+	for _, w := range s.lists {
+		if w.Owner == owner {
+			out = append(out, clone(w))
+		}
+	}
+
+	slices.SortFunc(out, func(a, b Watchlist) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return out, nil
+}
+
+func (s *MemoryStore) Update(_ context.Context, w Watchlist) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.lists[w.ID]
+	if !ok || existing.Owner != w.Owner {
+		return ErrNotFound
+	}
+
+	if s.nameTaken(w) {
+		return ErrDuplicateName
+	}
+
+	s.lists[w.ID] = clone(w)
+
+	return nil
+}
+
+func (s *MemoryStore) Delete(_ context.Context, owner, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, ok := s.lists[id]
+	if !ok || w.Owner != owner {
+		return ErrNotFound
+	}
+
+	delete(s.lists, id)
+
+	return nil
+}
+
+func (s *MemoryStore) Symbols(_ context.Context) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var all []string
+	for _, w := range s.lists {
+		all = append(all, w.Symbols...)
+	}
+
+	slices.Sort(all)
+
+	return slices.Compact(all), nil
+}
+
+// nameTaken reports whether another list of w's owner already uses w.Name.
+func (s *MemoryStore) nameTaken(w Watchlist) bool {
+	for _, other := range s.lists {
+		if other.ID != w.ID && other.Owner == w.Owner && strings.EqualFold(other.Name, w.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+func clone(w Watchlist) Watchlist {
+	w.Symbols = slices.Clone(w.Symbols)
+	return w
+}
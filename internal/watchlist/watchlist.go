@@ -0,0 +1,276 @@
+package watchlist
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNotFound      = errors.New("watchlist not found")
+	ErrDuplicateName = errors.New("watchlist name already in use")
+	ErrMissingName   = errors.New("watchlist name is required")
+	ErrInvalidSymbol = errors.New("invalid symbol")
+)
+
+var symbolPattern = regexp.MustCompile(`^[A-Z0-9][A-Z0-9.\-:/^=]{0,31}$`)
+
+// retryInterval is how often Run retries a reconcile that failed after a
+// watchlist change.
+const retryInterval = 30 * time.Second
+
+// Watchlist is a named set of symbols owned by one API key.
+type Watchlist struct {
+	ID        string    `json:"id"`
+	Owner     string    `json:"-"`
+	Name      string    `json:"name"`
+	Symbols   []string  `json:"symbols"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Subscriber is told which symbols start or stop being watched by anyone, so
+// the ingestion pipeline can manage its upstream streams.
+type Subscriber interface {
+	Subscribe(ctx context.Context, symbols []string) error
+	Unsubscribe(ctx context.Context, symbols []string) error
+}
+
+// Service manages watchlists and keeps the Subscriber in step with the union
+// of symbols across all of them. A change is committed once the store accepts
+// it; if the Subscriber then fails, Run retries in the background.
+type Service struct {
+	store      Store
+	subscriber Subscriber
+	now        func() time.Time
+
+	mu      sync.Mutex // serialises mutations so union diffs are consistent
+	tracked []string
+	stale   bool // a reconcile failed and tracked may lag the store
+}
+
+// NewService returns a Service over store. subscriber may be nil when nothing
+// consumes subscription changes.
+func NewService(store Store, subscriber Subscriber) *Service {
+	return &Service{
+		store:      store,
+		subscriber: subscriber,
+		now:        time.Now,
+	}
+}
+
+// Sync subscribes to the current union of watched symbols. Call it once at
+// startup, before serving requests.
+func (s *Service) Sync(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.reconcile(ctx)
+}
+
+// Tracked returns the symbols currently subscribed on behalf of watchlists.
+func (s *Service) Tracked() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.tracked)
+}
+
+// List returns the watchlists owned by owner.
+func (s *Service) List(ctx context.Context, owner string) ([]Watchlist, error) {
+	return s.store.List(ctx, owner)
+}
+
+// Get returns the watchlist id if it belongs to owner.
+func (s *Service) Get(ctx context.Context, owner, id string) (Watchlist, error) {
+	return s.store.Get(ctx, owner, id)
+}
+
+// Create adds a watchlist for owner.
+func (s *Service) Create(ctx context.Context, owner, name string, symbols []string) (Watchlist, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return Watchlist{}, ErrMissingName
+	}
+
+	normalized, err := normalizeSymbols(symbols)
+	if err != nil {
+		return Watchlist{}, err
+	}
+
+	id, err := newID()
+	if err != nil {
+		return Watchlist{}, err
+	}
+
+	now := s.now().UTC()
+	w := Watchlist{
+		ID:        id,
+		Owner:     owner,
+		Name:      name,
+		Symbols:   normalized,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.store.Create(ctx, w); err != nil {
+		return Watchlist{}, err
+	}
+
+	s.reconcileAfterWrite(ctx)
+
+	return w, nil
+}
+
+// Update replaces the name and symbols of watchlist id.
+func (s *Service) Update(ctx context.Context, owner, id, name string, symbols []string) (Watchlist, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return Watchlist{}, ErrMissingName
+	}
+
+	normalized, err := normalizeSymbols(symbols)
+	if err != nil {
+		return Watchlist{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, err := s.store.Get(ctx, owner, id)
+	if err != nil {
+		return Watchlist{}, err
+	}
+
+	w.Name = name
+	w.Symbols = normalized
+	w.UpdatedAt = s.now().UTC()
+
+	if err := s.store.Update(ctx, w); err != nil {
+		return Watchlist{}, err
+	}
+
+	s.reconcileAfterWrite(ctx)
+
+	return w, nil
+}
+
+// Delete removes watchlist id.
+func (s *Service) Delete(ctx context.Context, owner, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.store.Delete(ctx, owner, id); err != nil {
+		return err
+	}
+
+	s.reconcileAfterWrite(ctx)
+
+	return nil
+}
+
+// Run retries failed reconciles every retryInterval until ctx is cancelled;
+// wrap it with app.NewBackground.
+func (s *Service) Run(ctx context.Context) error {
+	ticker := time.NewTicker(retryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			s.retryReconcile(ctx)
+		}
+	}
+}
+
+// retryReconcile reconciles again if the last attempt failed.
+func (s *Service) retryReconcile(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stale {
+		s.reconcileAfterWrite(ctx)
+	}
+}
+
+// reconcileAfterWrite reconciles once a change is in the store. The change
+// stands either way, so a failure is left for Run to retry rather than
+// reported to the caller. Callers must hold s.mu.
+func (s *Service) reconcileAfterWrite(ctx context.Context) {
+	s.stale = s.reconcile(context.WithoutCancel(ctx)) != nil
+}
+
+// reconcile diffs the union of watched symbols against what was last
+// subscribed and forwards the changes. Callers must hold s.mu.
+func (s *Service) reconcile(ctx context.Context) error {
+	union, err := s.store.Symbols(ctx)
+	if err != nil {
+		return fmt.Errorf("loading watched symbols: %w", err)
+	}
+
+	var added, removed []string
+	for _, sym := range union {
+		if _, ok := slices.BinarySearch(s.tracked, sym); !ok {
+			added = append(added, sym)
+		}
+	}
+	for _, sym := range s.tracked {
+		if _, ok := slices.BinarySearch(union, sym); !ok {
+			removed = append(removed, sym)
+		}
+	}
+
+	if s.subscriber != nil {
+		if len(added) > 0 {
+			if err := s.subscriber.Subscribe(ctx, added); err != nil {
+				return fmt.Errorf("subscribing %v: %w", added, err)
+			}
+		}
+		if len(removed) > 0 {
+			if err := s.subscriber.Unsubscribe(ctx, removed); err != nil {
+				return fmt.Errorf("unsubscribing %v: %w", removed, err)
+			}
+		}
+	}
+
+	s.tracked = union
+
+	return nil
+}
+
+// normalizeSymbols upper-cases, validates, sorts, and de-duplicates symbols.
+func normalizeSymbols(symbols []string) ([]string, error) {
+	out := make([]string, 0, len(symbols))
+
+	for _, sym := range symbols {
+		sym = strings.ToUpper(strings.TrimSpace(sym))
+		if !symbolPattern.MatchString(sym) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidSymbol, sym)
+		}
+		out = append(out, sym)
+	}
+
+	slices.Sort(out)
+
+	return slices.Compact(out), nil
+}
+
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating watchlist id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
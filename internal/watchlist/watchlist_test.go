@@ -0,0 +1,150 @@
+package watchlist
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type recordingSubscriber struct {
+	subscribed   [][]string
+	unsubscribed [][]string
+	err          error // returned by Subscribe when set
+}
+
+func (r *recordingSubscriber) Subscribe(_ context.Context, symbols []string) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.subscribed = append(r.subscribed, symbols)
+	return nil
+}
+
+func (r *recordingSubscriber) Unsubscribe(_ context.Context, symbols []string) error {
+	r.unsubscribed = append(r.unsubscribed, symbols)
+	return nil
+}
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("subscriptions follow union of watchlists", func(t *testing.T) {
+		sub := &recordingSubscriber{}
+		svc := NewService(NewMemoryStore(), sub)
+
+		a, err := svc.Create(ctx, "key-a", "tech", []string{"aapl", "MSFT", "AAPL"})
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if !reflect.DeepEqual(a.Symbols, []string{"AAPL", "MSFT"}) {
+			t.Errorf("expected normalized symbols, got %v", a.Symbols)
+		}
+
+		b, err := svc.Create(ctx, "key-b", "mine", []string{"MSFT", "BTC-USD"})
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+
+		if _, err := svc.Update(ctx, "key-a", a.ID, "tech", []string{"AAPL"}); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if err := svc.Delete(ctx, "key-b", b.ID); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+
+		wantSub := [][]string{{"AAPL", "MSFT"}, {"BTC-USD"}}
+		if !reflect.DeepEqual(sub.subscribed, wantSub) {
+			t.Errorf("expected subscriptions %v, got %v", wantSub, sub.subscribed)
+		}
+		wantUnsub := [][]string{{"BTC-USD", "MSFT"}}
+		if !reflect.DeepEqual(sub.unsubscribed, wantUnsub) {
+			t.Errorf("expected unsubscriptions %v, got %v", wantUnsub, sub.unsubscribed)
+		}
+		if got := svc.Tracked(); !reflect.DeepEqual(got, []string{"AAPL"}) {
+			t.Errorf("expected tracked symbols [AAPL], got %v", got)
+		}
+	})
+
+	t.Run("sync subscribes existing symbols", func(t *testing.T) {
+		store := NewMemoryStore()
+		store.Create(ctx, Watchlist{ID: "1", Owner: "k", Name: "n", Symbols: []string{"ETH-USD"}})
+
+		sub := &recordingSubscriber{}
+		if err := NewService(store, sub).Sync(ctx); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if !reflect.DeepEqual(sub.subscribed, [][]string{{"ETH-USD"}}) {
+			t.Errorf("expected ETH-USD subscription, got %v", sub.subscribed)
+		}
+	})
+
+	t.Run("failed subscription is retried after commit", func(t *testing.T) {
+		sub := &recordingSubscriber{err: errors.New("upstream down")}
+		svc := NewService(NewMemoryStore(), sub)
+
+		w, err := svc.Create(ctx, "key-a", "tech", []string{"AAPL"})
+		if err != nil {
+			t.Fatalf("expected the committed watchlist to be returned, got: %v", err)
+		}
+		if _, err := svc.Get(ctx, "key-a", w.ID); err != nil {
+			t.Fatalf("expected the watchlist to be stored, got: %v", err)
+		}
+		if got := svc.Tracked(); len(got) != 0 {
+			t.Fatalf("expected nothing tracked yet, got %v", got)
+		}
+
+		sub.err = nil
+		svc.retryReconcile(ctx)
+		if got := svc.Tracked(); !reflect.DeepEqual(got, []string{"AAPL"}) {
+			t.Errorf("expected the retry to subscribe AAPL, got %v", got)
+		}
+
+		svc.retryReconcile(ctx)
+		if len(sub.subscribed) != 1 {
+			t.Errorf("expected no retry once reconciled, got %v", sub.subscribed)
+		}
+	})
+
+	t.Run("owners are isolated", func(t *testing.T) {
+		svc := NewService(NewMemoryStore(), nil)
+
+		w, err := svc.Create(ctx, "key-a", "tech", []string{"AAPL"})
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+
+		if _, err := svc.Get(ctx, "key-b", w.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected error %v, got: %v", ErrNotFound, err)
+		}
+		if err := svc.Delete(ctx, "key-b", w.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected error %v, got: %v", ErrNotFound, err)
+		}
+		if _, err := svc.Create(ctx, "key-b", "tech", nil); err != nil {
+			t.Errorf("expected names to be unique per owner only, got: %v", err)
+		}
+	})
+
+	tests := []struct {
+		name    string
+		list    string
+		symbols []string
+		wantErr error
+	}{
+		{name: "missing name", list: "  ", wantErr: ErrMissingName},
+		{name: "invalid symbol", list: "x", symbols: []string{"AA PL"}, wantErr: ErrInvalidSymbol},
+		{name: "duplicate name", list: "Tech", wantErr: ErrDuplicateName},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewService(NewMemoryStore(), nil)
+			if _, err := svc.Create(ctx, "key-a", "tech", nil); err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+
+			if _, err := svc.Create(ctx, "key-a", tt.list, tt.symbols); !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected error %v, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}